@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -26,41 +28,214 @@ var regexCache = make(map[string]*regexp.Regexp)
 
 // GenerateAliases returns a map of flag keys to aliases based on config.
 func GenerateAliases(flags []string, aliases []options.Alias, dir string) (map[string][]string, error) {
+	generated, err := GenerateAliasList(flags, aliases, dir)
+	if err != nil {
+		return nil, err
+	}
+	return AliasesByFlagKey(flags, generated), nil
+}
+
+// GenerateAliasList returns every alias generated for the given flag keys, along with the alias configuration that produced it.
+func GenerateAliasList(flags []string, aliases []options.Alias, dir string) ([]GeneratedAlias, error) {
 	allFileContents, err := processFileContent(aliases, dir)
 	if err != nil {
 		return nil, err
 	}
+	warnCaseMismatchedLiteralKeys(flags, aliases)
 
 	// Filepattern contents concatenated once per alias; rebuilding them for
 	// every flag key multiplies peak memory by the number of flags.
 	patternContents := make(map[int]string, len(aliases))
+	// File scoped filepattern contents are kept separate per file, so each alias can be scoped to the file that defined it
+	patternContentsByFile := make(map[int]map[string]string, len(aliases))
+	// Assignments found by auto aliases, by assigned value
+	autoAssignments := make(map[int]map[string][]autoAssignment, len(aliases))
 
-	ret := make(map[string][]string, len(flags))
+	ret := make([]GeneratedAlias, 0, len(flags))
 	for _, flag := range flags {
 		for i, a := range aliases {
 			if a.Name == "" {
 				a.Name = strconv.Itoa(i)
 			}
+			a.Scope = a.CanonicalScope()
+			fileScoped := a.Scope == options.FileScope || a.Scope == options.PackageScope
 			if a.Type.Canonical() == options.FilePattern {
-				if _, ok := patternContents[i]; !ok {
+				if _, ok := patternContents[i]; !ok && !fileScoped {
 					contents, err := concatFilePatternContents(a, dir, allFileContents)
 					if err != nil {
 						return nil, err
 					}
 					patternContents[i] = contents
 				}
+				if _, ok := patternContentsByFile[i]; !ok && fileScoped {
+					contents, err := filePatternContentsByFile(a, dir, allFileContents)
+					if err != nil {
+						return nil, err
+					}
+					patternContentsByFile[i] = contents
+				}
+			}
+
+			if _, ok := autoAssignments[i]; !ok && a.Type.Canonical() == options.Auto {
+				assignments, err := findAutoAssignments(a, dir)
+				if err != nil {
+					return nil, err
+				}
+				autoAssignments[i] = assignments
+			}
+
+			var flagAliases []GeneratedAlias
+			var err error
+			if a.Type.Canonical() == options.Auto {
+				flagAliases = generateAutoAlias(a, flag, autoAssignments[i])
+			} else if fileScoped {
+				flagAliases, err = generateFileScopedAlias(a, flag, dir, patternContentsByFile[i])
+			} else {
+				flagAliases, err = generateUnscopedAlias(a, flag, dir, patternContents[i])
 			}
-			flagAliases, err := generateAlias(a, flag, dir, patternContents[i])
 			if err != nil {
 				return nil, err
 			}
-			ret[flag] = append(ret[flag], flagAliases...)
+			for _, g := range mergeAliasScopes(flagAliases) {
+				g.FlagKey = flag
+				g.Source = a.Name
+				g.Type = a.Type.Canonical()
+				ret = append(ret, g)
+			}
+		}
+	}
+	return ret, nil
+}
+
+// AliasesByFlagKey groups generated aliases by flag key. Every flag key is present in the returned map, even if no aliases were generated for it.
+func AliasesByFlagKey(flags []string, generated []GeneratedAlias) map[string][]string {
+	ret := make(map[string][]string, len(flags))
+	for _, flag := range flags {
+		ret[flag] = nil
+	}
+	for _, g := range generated {
+		ret[g.FlagKey] = append(ret[g.FlagKey], g.Alias)
+	}
+	for flag, flagAliases := range ret {
+		ret[flag] = helpers.Dedupe(flagAliases)
+	}
+	return ret
+}
+
+// AliasScopesByFlagKey returns the paths or globs each scoped alias is limited to, by flag key and alias.
+// Aliases that are not scoped to any files are omitted.
+func AliasScopesByFlagKey(generated []GeneratedAlias) map[string]map[string][]string {
+	ret := map[string]map[string][]string{}
+	unscoped := map[string]map[string]bool{}
+	for _, g := range generated {
+		if unscoped[g.FlagKey] == nil {
+			unscoped[g.FlagKey] = map[string]bool{}
+		}
+		if len(g.Scope) == 0 {
+			unscoped[g.FlagKey][g.Alias] = true
+			continue
+		}
+		if ret[g.FlagKey] == nil {
+			ret[g.FlagKey] = map[string][]string{}
+		}
+		ret[g.FlagKey][g.Alias] = helpers.Dedupe(append(ret[g.FlagKey][g.Alias], g.Scope...))
+	}
+	// an alias that is also generated without a scope may be used anywhere
+	for flag, scopes := range ret {
+		for alias := range scopes {
+			if unscoped[flag][alias] {
+				delete(scopes, alias)
+			}
+		}
+		if len(scopes) == 0 {
+			delete(ret, flag)
+		}
+	}
+	return ret
+}
+
+// generateUnscopedAlias generates aliases that are either usable in every file, or scoped to the glob configured for the alias
+func generateUnscopedAlias(a options.Alias, flag, dir string, patternContents string) ([]GeneratedAlias, error) {
+	aliases, err := generateAlias(a, flag, dir, patternContents)
+	if err != nil {
+		return nil, err
+	}
+	var scope []string
+	if a.Scope != "" {
+		scope = []string{a.Scope}
+	}
+	ret := make([]GeneratedAlias, 0, len(aliases))
+	for _, alias := range aliases {
+		ret = append(ret, GeneratedAlias{Alias: alias, Scope: scope})
+	}
+	return ret, nil
+}
+
+// generateFileScopedAlias generates aliases that are scoped to the file that defined them
+func generateFileScopedAlias(a options.Alias, flag, dir string, contentsByFile map[string]string) ([]GeneratedAlias, error) {
+	ret := []GeneratedAlias{}
+	switch a.Type.Canonical() {
+	case options.FilePattern:
+		for path, contents := range contentsByFile {
+			for _, alias := range matchFilePatternAliases(a, flag, contents) {
+				ret = append(ret, GeneratedAlias{Alias: alias, Scope: definitionScope(a.Scope, path)})
+			}
+		}
+	case options.Command:
+		aliases, err := runAliasCommand(a, flag, dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, alias := range aliases {
+			g := GeneratedAlias{Alias: alias.Alias}
+			if alias.Path != "" {
+				g.Scope = definitionScope(a.Scope, path.Clean(filepath.ToSlash(alias.Path)))
+			}
+			ret = append(ret, g)
 		}
-		ret[flag] = helpers.Dedupe(ret[flag])
 	}
 	return ret, nil
 }
 
+// definitionScope returns the scope of an alias defined in a file, relative to the repository root
+func definitionScope(scope, definitionPath string) []string {
+	switch scope {
+	case "":
+		return nil
+	case options.FileScope:
+		return []string{definitionPath}
+	case options.PackageScope:
+		if dir := path.Dir(definitionPath); dir != "." {
+			return []string{dir + "/*"}
+		}
+		return []string{"*"}
+	}
+	return []string{scope}
+}
+
+// mergeAliasScopes combines the scopes of duplicate aliases. An alias that is generated at least once without a scope is not scoped.
+func mergeAliasScopes(generated []GeneratedAlias) []GeneratedAlias {
+	ret := make([]GeneratedAlias, 0, len(generated))
+	indexByAlias := make(map[string]int, len(generated))
+	for _, g := range generated {
+		i, ok := indexByAlias[g.Alias]
+		if !ok {
+			indexByAlias[g.Alias] = len(ret)
+			ret = append(ret, g)
+			continue
+		}
+		if len(ret[i].Scope) == 0 || len(g.Scope) == 0 {
+			ret[i].Scope = nil
+		} else {
+			ret[i].Scope = helpers.Dedupe(append(ret[i].Scope, g.Scope...))
+		}
+	}
+	for i := range ret {
+		sort.Strings(ret[i].Scope)
+	}
+	return ret
+}
+
 func generateAlias(a options.Alias, flag, dir string, patternContents string) (ret []string, err error) {
 	switch a.Type.Canonical() {
 	case options.Literal:
@@ -70,14 +245,57 @@ func generateAlias(a options.Alias, flag, dir string, patternContents string) (r
 	case options.Command:
 		ret, err = GenerateAliasesFromCommand(a, flag, dir)
 	default:
-		var alias string
-		alias, err = GenerateNamingConventionAlias(a, flag)
-		ret = []string{alias}
+		ret, err = GenerateNamingConventionAliases(a, flag)
 	}
 
 	return ret, err
 }
 
+// warnCaseMismatchedLiteralKeys warns about literal alias keys that only match a flag key when ignoring case.
+// Literal alias keys used to be lowercased when the configuration file was read, so these configurations
+// matched flags with lowercase keys, and will no longer match them.
+func warnCaseMismatchedLiteralKeys(flags []string, aliases []options.Alias) {
+	flagsByLowerKey := make(map[string]string, len(flags))
+	exactFlags := make(map[string]struct{}, len(flags))
+	for _, flag := range flags {
+		flagsByLowerKey[strings.ToLower(flag)] = flag
+		exactFlags[flag] = struct{}{}
+	}
+	for i, a := range aliases {
+		if a.Type.Canonical() != options.Literal {
+			continue
+		}
+		aliasId := strconv.Itoa(i)
+		if a.Name != "" {
+			aliasId = a.Name
+		}
+		for key := range a.Flags {
+			if _, ok := exactFlags[key]; ok {
+				continue
+			}
+			if flag, ok := flagsByLowerKey[strings.ToLower(key)]; ok {
+				log.Warning.Printf("literal '%s': key '%s' does not match any flag key, but matches flag key '%s' when ignoring case. Literal alias keys are case-sensitive, rename the key to '%s'", aliasId, key, flag, flag)
+			}
+		}
+	}
+}
+
+// GenerateNamingConventionAliases returns the naming convention aliases for a flag key. Without case options, a single alias
+// is generated using the default word splitting rules.
+func GenerateNamingConventionAliases(a options.Alias, flag string) ([]string, error) {
+	if a.CaseOptions == nil {
+		alias, err := GenerateNamingConventionAlias(a, flag)
+		if err != nil {
+			return nil, err
+		}
+		return []string{alias}, nil
+	}
+	if !a.Type.IsNamingConvention() {
+		return nil, fmt.Errorf("naming convention alias type %s not recognized", a.Type)
+	}
+	return generateCaseOptionsAliases(a.Type, flag, *a.CaseOptions), nil
+}
+
 func GenerateNamingConventionAlias(a options.Alias, flag string) (alias string, err error) {
 	switch a.Type.Canonical() {
 	case options.CamelCase:
@@ -125,6 +343,34 @@ func concatFilePatternContents(a options.Alias, dir string, allFileContents File
 	return sb.String(), nil
 }
 
+// filePatternContentsByFile returns the contents of each file matched by the alias paths, by path relative to dir
+func filePatternContentsByFile(a options.Alias, dir string, allFileContents FileContentsMap) (map[string]string, error) {
+	ret := map[string]string{}
+	for _, path := range a.Paths {
+		matches, err := cacheFilepathGlob(dir, path)
+		if err != nil {
+			return nil, fmt.Errorf("filepattern '%s': could not process path glob '%s'", a.Name, path)
+		}
+		for _, match := range matches {
+			if pathFileContents := allFileContents[match]; len(pathFileContents) > 0 {
+				ret[relativePath(dir, match)] = string(pathFileContents)
+			}
+		}
+	}
+	return ret, nil
+}
+
+func relativePath(dir, path string) string {
+	if dir == "" {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
 func matchFilePatternAliases(a options.Alias, flag, fileContents string) []string {
 	ret := []string{}
 	for _, p := range a.Patterns {
@@ -145,7 +391,34 @@ func matchFilePatternAliases(a options.Alias, flag, fileContents string) []strin
 }
 
 func GenerateAliasesFromCommand(a options.Alias, flag, dir string) ([]string, error) {
-	ret := []string{}
+	aliases, err := runAliasCommand(a, flag, dir)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		ret = append(ret, alias.Alias)
+	}
+	return ret, nil
+}
+
+// commandAlias is an alias output by an alias command. Commands may output either a plain alias string, or an object with the
+// path of the file that defined the alias, which is used to scope the alias to that file.
+type commandAlias struct {
+	Alias string `json:"alias"`
+	Path  string `json:"path,omitempty"`
+}
+
+func (c *commandAlias) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &c.Alias); err == nil {
+		return nil
+	}
+	type plainCommandAlias commandAlias
+	return json.Unmarshal(data, (*plainCommandAlias)(c))
+}
+
+func runAliasCommand(a options.Alias, flag, dir string) ([]commandAlias, error) {
+	ret := []commandAlias{}
 	ctx := context.Background()
 	if a.Timeout != nil && *a.Timeout > 0 {
 		var cancel context.CancelFunc
@@ -173,10 +446,11 @@ func GenerateAliasesFromCommand(a options.Alias, flag, dir string) ([]string, er
 	return ret, err
 }
 
-// processFileContent reads and stores the content of files specified by filePattern alias matchers to be matched for aliases
+// processFileContent reads and stores the content of files specified by filePattern and auto alias matchers to be matched for aliases
 func processFileContent(aliases []options.Alias, dir string) (FileContentsMap, error) {
 	allFileContents := map[string][]byte{}
 	for idx, a := range aliases {
+		// auto aliases read their files one at a time in findAutoAssignments
 		if a.Type.Canonical() != options.FilePattern {
 			continue
 		}
@@ -190,10 +464,10 @@ func processFileContent(aliases []options.Alias, dir string) (FileContentsMap, e
 		for _, glob := range a.Paths {
 			matches, err := cacheFilepathGlob(dir, glob)
 			if err != nil {
-				return nil, fmt.Errorf("filepattern '%s': could not process path glob '%s'", aliasId, glob)
+				return nil, fmt.Errorf("%s '%s': could not process path glob '%s'", a.Type.Canonical(), aliasId, glob)
 			}
 			if matches == nil {
-				log.Info.Printf("filepattern '%s': no matching files found for alias path glob '%s'", aliasId, glob)
+				log.Info.Printf("%s '%s': no matching files found for alias path glob '%s'", a.Type.Canonical(), aliasId, glob)
 			}
 			paths = append(paths, matches...)
 		}
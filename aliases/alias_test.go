@@ -2,6 +2,7 @@ package aliases
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -132,6 +133,230 @@ func Test_GenerateAliases(t *testing.T) {
 	}
 }
 
+func Test_GenerateAliasList(t *testing.T) {
+	named := alias(o.PascalCase)
+	named.Name = "pascal"
+	generated, err := GenerateAliasList(slice(testFlagKey), []o.Alias{alias(o.CamelCase), named}, "")
+	require.NoError(t, err)
+	assert.Equal(t, []GeneratedAlias{
+		{FlagKey: testFlagKey, Alias: "someFlag", Source: "0", Type: o.CamelCase},
+		{FlagKey: testFlagKey, Alias: "SomeFlag", Source: "pascal", Type: o.PascalCase},
+	}, generated)
+}
+
+func Test_GenerateAliasList_scopes(t *testing.T) {
+	for _, scope := range []string{o.FileScope, "File"} {
+		t.Run("file scope "+scope, func(t *testing.T) {
+			a := fileWildPattern()
+			a.Scope = scope
+			require.NoError(t, a.IsValid())
+			generated, err := GenerateAliasList(slice(testWildFlagKey), []o.Alias{a}, "")
+			require.NoError(t, err)
+			assert.ElementsMatch(t, []GeneratedAlias{
+				{FlagKey: testWildFlagKey, Alias: "WILD_FLAG", Source: "0", Type: o.FilePattern, Scope: slice("testdata/wild/alias_test.txt")},
+				{FlagKey: testWildFlagKey, Alias: "WILD_FLAG_SECOND_ALIAS", Source: "0", Type: o.FilePattern, Scope: slice("testdata/wild/nested-wild/alias_test.txt")},
+				{FlagKey: testWildFlagKey, Alias: "ABSOLUTELY_WILD", Source: "0", Type: o.FilePattern, Scope: slice("testdata/wild/nested-wild/another/another/alias_test.txt")},
+			}, generated)
+		})
+	}
+	t.Run("glob scope", func(t *testing.T) {
+		a := fileExactPattern()
+		a.Scope = "src/**/*.go"
+		generated, err := GenerateAliasList(slice(testFlagKey), []o.Alias{a}, "")
+		require.NoError(t, err)
+		assert.Equal(t, []GeneratedAlias{
+			{FlagKey: testFlagKey, Alias: "SOME_FLAG", Source: "0", Type: o.FilePattern, Scope: slice("src/**/*.go")},
+		}, generated)
+	})
+}
+
+func Test_AliasScopesByFlagKey(t *testing.T) {
+	generated := []GeneratedAlias{
+		{FlagKey: testFlagKey, Alias: "SOME_FLAG", Scope: slice("a.go")},
+		{FlagKey: testFlagKey, Alias: "SOME_FLAG", Scope: slice("b.go")},
+		{FlagKey: testFlagKey, Alias: "someFlag"},
+		{FlagKey: testFlagKey2, Alias: "ANOTHER_FLAG", Scope: slice("c.go")},
+		{FlagKey: testFlagKey2, Alias: "ANOTHER_FLAG"},
+	}
+	assert.Equal(t, map[string]map[string][]string{
+		testFlagKey: {"SOME_FLAG": slice("a.go", "b.go")},
+	}, AliasScopesByFlagKey(generated))
+}
+
+func Test_GenerateNamingConventionAliases(t *testing.T) {
+	acronyms := &o.CaseOptions{Acronyms: slice("API", "ID")}
+	specs := []struct {
+		name        string
+		aliasType   o.AliasType
+		flag        string
+		caseOptions *o.CaseOptions
+		want        []string
+	}{
+		{name: "defaults without case options", aliasType: o.CamelCase, flag: "enable-api-v2", want: slice("enableApiV2")},
+		{name: "camelcase acronyms", aliasType: o.CamelCase, flag: "enable-api-v2", caseOptions: acronyms, want: slice("enableAPIV2")},
+		{name: "camelcase leading acronym", aliasType: o.CamelCase, flag: "api-id", caseOptions: acronyms, want: slice("apiID")},
+		{name: "pascalcase acronyms", aliasType: o.PascalCase, flag: "user_id", caseOptions: acronyms, want: slice("UserID")},
+		{name: "snakecase attaches numbers", aliasType: o.SnakeCase, flag: "enable-api-v2", caseOptions: acronyms, want: slice("enable_api_v2")},
+		{name: "snakecase splits numbers", aliasType: o.SnakeCase, flag: "enable-api-v2", caseOptions: &o.CaseOptions{Numbers: o.SplitNumbers}, want: slice("enable_api_v_2")},
+		{name: "uppersnakecase", aliasType: o.UpperSnakeCase, flag: "enableAPIKey", caseOptions: acronyms, want: slice("ENABLE_API_KEY")},
+		{name: "kebabcase", aliasType: o.KebabCase, flag: "HTTPServer2", caseOptions: acronyms, want: slice("http-server2")},
+		{name: "dotcase", aliasType: o.DotCase, flag: "enable-api-v2", caseOptions: acronyms, want: slice("enable.api.v2")},
+		{
+			name:        "variants",
+			aliasType:   o.CamelCase,
+			flag:        "enable-api-v2",
+			caseOptions: &o.CaseOptions{Acronyms: slice("API"), Variants: true},
+			want:        slice("enableAPIV2", "enableApiV2"),
+		},
+		{
+			name:        "snakecase variants",
+			aliasType:   o.SnakeCase,
+			flag:        "enable-api-v2",
+			caseOptions: &o.CaseOptions{Variants: true},
+			want:        slice("enable_api_v2", "enable_api_v_2"),
+		},
+	}
+
+	for _, tt := range specs {
+		t.Run(tt.name, func(t *testing.T) {
+			a := alias(tt.aliasType)
+			a.CaseOptions = tt.caseOptions
+			aliases, err := GenerateNamingConventionAliases(a, tt.flag)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, aliases)
+		})
+	}
+}
+
+func Test_GenerateAliasList_auto(t *testing.T) {
+	specs := []struct {
+		name  string
+		paths []string
+		scope string
+		want  []GeneratedAlias
+	}{
+		{
+			name:  "all supported files",
+			paths: slice("testdata/auto/**"),
+			want: []GeneratedAlias{
+				{FlagKey: testFlagKey, Alias: "SomeFlag", Source: "0", Type: o.Auto},
+				{FlagKey: testFlagKey, Alias: "SOME_FLAG", Source: "0", Type: o.Auto},
+				{FlagKey: testFlagKey2, Alias: "AnotherFlag", Source: "0", Type: o.Auto},
+			},
+		},
+		{
+			name:  "file scope",
+			paths: slice("testdata/auto/**/*.ts"),
+			scope: o.FileScope,
+			want: []GeneratedAlias{
+				{FlagKey: testFlagKey, Alias: "SOME_FLAG", Source: "0", Type: o.Auto, Scope: slice("testdata/auto/web/flags.ts")},
+			},
+		},
+		{
+			name:  "package scope",
+			paths: slice("testdata/auto/*.go"),
+			scope: o.PackageScope,
+			want: []GeneratedAlias{
+				{FlagKey: testFlagKey, Alias: "SomeFlag", Source: "0", Type: o.Auto, Scope: slice("testdata/auto/*")},
+				{FlagKey: testFlagKey2, Alias: "AnotherFlag", Source: "0", Type: o.Auto, Scope: slice("testdata/auto/*")},
+			},
+		},
+	}
+
+	for _, tt := range specs {
+		t.Run(tt.name, func(t *testing.T) {
+			a := alias(o.Auto)
+			a.Paths = tt.paths
+			a.Scope = tt.scope
+			generated, err := GenerateAliasList(slice(testFlagKey, testFlagKey2), []o.Alias{a}, "")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, generated)
+		})
+	}
+}
+
+func Test_FindCollisions(t *testing.T) {
+	specs := []struct {
+		name      string
+		flags     []string
+		generated []GeneratedAlias
+		want      []Collision
+	}{
+		{
+			name:  "no collisions",
+			flags: slice(testFlagKey, testFlagKey2),
+			generated: []GeneratedAlias{
+				{FlagKey: testFlagKey, Alias: "SomeFlag", Source: "0"},
+				{FlagKey: testFlagKey2, Alias: "AnotherFlag", Source: "0"},
+			},
+			want: []Collision{},
+		},
+		{
+			name:  "alias generated for multiple flags",
+			flags: slice(testFlagKey, testFlagKey2),
+			generated: []GeneratedAlias{
+				{FlagKey: testFlagKey, Alias: "featureFlag", Source: "0"},
+				{FlagKey: testFlagKey2, Alias: "featureFlag", Source: "1"},
+			},
+			want: []Collision{{Alias: "featureFlag", FlagKeys: slice(testFlagKey2, testFlagKey), Sources: slice("0", "1")}},
+		},
+		{
+			name:  "alias identical to another flag key",
+			flags: slice(testFlagKey, testFlagKey2),
+			generated: []GeneratedAlias{
+				{FlagKey: testFlagKey, Alias: testFlagKey2, Source: "0"},
+			},
+			want: []Collision{{Alias: testFlagKey2, FlagKeys: slice(testFlagKey2, testFlagKey), Sources: slice("0")}},
+		},
+		{
+			name:  "alias identical to its own flag key",
+			flags: slice(testFlagKey),
+			generated: []GeneratedAlias{
+				{FlagKey: testFlagKey, Alias: testFlagKey, Source: "0"},
+			},
+			want: []Collision{},
+		},
+	}
+
+	for _, tt := range specs {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FindCollisions(tt.flags, tt.generated))
+		})
+	}
+}
+
+func Test_WeakAliasReason(t *testing.T) {
+	assert.NotEmpty(t, WeakAliasReason("ab"))
+	assert.NotEmpty(t, WeakAliasReason("Enabled"))
+	assert.Empty(t, WeakAliasReason("enableWidgets"))
+}
+
+func Test_ApplyCollisionPolicy(t *testing.T) {
+	flags := slice(testFlagKey, testFlagKey2)
+	generated := []GeneratedAlias{
+		{FlagKey: testFlagKey, Alias: "featureFlag", Source: "0"},
+		{FlagKey: testFlagKey, Alias: "SomeFlag", Source: "0"},
+		{FlagKey: testFlagKey2, Alias: "featureFlag", Source: "0"},
+	}
+
+	t.Run("allow", func(t *testing.T) {
+		got, err := ApplyCollisionPolicy(o.AllowCollisions, flags, generated)
+		require.NoError(t, err)
+		assert.Equal(t, generated, got)
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		got, err := ApplyCollisionPolicy(o.DropCollisions, flags, generated)
+		require.NoError(t, err)
+		assert.Equal(t, []GeneratedAlias{{FlagKey: testFlagKey, Alias: "SomeFlag", Source: "0"}}, got)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := ApplyCollisionPolicy(o.ErrorCollisions, flags, generated)
+		require.Error(t, err)
+	})
+}
+
 func Test_processFileContent(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "")
 	if err != nil {
@@ -262,3 +487,25 @@ func cmd(command string, timeout int64) o.Alias {
 	a.Timeout = &timeout
 	return a
 }
+
+func Test_GenerateAliasList_autoIgnoredFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(path, contents string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(contents), 0o600))
+	}
+	writeFile(".ldignore", "node_modules\n")
+	writeFile("flags.go", `const NewCheckout = "new-checkout"`)
+	writeFile("node_modules/lib/flags.js", `export const VENDORED_CHECKOUT = "new-checkout"`)
+	writeFile(".cache/flags.go", `const CachedCheckout = "new-checkout"`)
+
+	a := alias(o.Auto)
+	generated, err := GenerateAliasList(slice("new-checkout"), []o.Alias{a}, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []GeneratedAlias{{FlagKey: "new-checkout", Alias: "NewCheckout", Source: "0", Type: o.Auto}}, generated)
+
+	a.Paths = slice("**/*.js")
+	generated, err = GenerateAliasList(slice("new-checkout"), []o.Alias{a}, dir)
+	require.NoError(t, err)
+	assert.Empty(t, generated)
+}
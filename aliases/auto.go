@@ -0,0 +1,106 @@
+package aliases
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/helpers"
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/ignore"
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/lang"
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/log"
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/validation"
+	"github.com/launchdarkly/ld-find-code-refs/v2/options"
+)
+
+// autoAssignment is an identifier assigned a string literal, and the path of the file where it is assigned
+type autoAssignment struct {
+	Name string
+	Path string
+}
+
+// autoAliasPaths returns the files searched by an auto alias: the files matching its paths if set, otherwise every file in a supported
+// language. Files ignored by the .gitignore, .ignore, or .ldignore files in the root of dir, and hidden directories, are not searched.
+func autoAliasPaths(a options.Alias, dir string) ([]string, error) {
+	root := dir
+	if root == "" {
+		root = "."
+	}
+	ignores := ignore.New(root, ignore.Files)
+
+	paths := []string{}
+	if len(a.Paths) > 0 {
+		for _, glob := range a.Paths {
+			matches, err := cacheFilepathGlob(dir, glob)
+			if err != nil {
+				return nil, fmt.Errorf("%s '%s': could not process path glob '%s'", a.Type.Canonical(), a.Name, glob)
+			}
+			if matches == nil {
+				log.Info.Printf("%s '%s': no matching files found for alias path glob '%s'", a.Type.Canonical(), a.Name, glob)
+			}
+			for _, match := range matches {
+				// globs may also match directories
+				if validation.FileExists(match) && !ignores.MatchPath(match) {
+					paths = append(paths, match)
+				}
+			}
+		}
+		return helpers.Dedupe(paths), nil
+	}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(d.Name(), ".") || ignores.Match(path, true)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && lang.ForPath(path) != nil && !ignores.Match(path, false) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// findAutoAssignments finds string literals assigned to identifiers in the files searched by an auto alias, by string value.
+// Files are read one at a time, so only the assignments are kept in memory.
+func findAutoAssignments(a options.Alias, dir string) (map[string][]autoAssignment, error) {
+	paths, err := autoAliasPaths(a, dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	ret := map[string][]autoAssignment{}
+	for _, path := range paths {
+		l := lang.ForPath(path)
+		if l == nil {
+			continue
+		}
+		/* #nosec */
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s '%s': could not process file at path '%s': %v", a.Type.Canonical(), a.Name, path, err)
+		}
+		for _, assignment := range lang.FindStringAssignments(l, string(data)) {
+			ret[assignment.Value] = append(ret[assignment.Value], autoAssignment{Name: assignment.Name, Path: relativePath(dir, path)})
+		}
+	}
+	return ret, nil
+}
+
+// generateAutoAlias generates an alias for each identifier assigned the flag key
+func generateAutoAlias(a options.Alias, flag string, assignmentsByValue map[string][]autoAssignment) []GeneratedAlias {
+	ret := []GeneratedAlias{}
+	for _, assignment := range assignmentsByValue[flag] {
+		ret = append(ret, GeneratedAlias{Alias: assignment.Name, Scope: definitionScope(a.Scope, assignment.Path)})
+	}
+	return ret
+}
@@ -0,0 +1,128 @@
+package aliases
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/helpers"
+	"github.com/launchdarkly/ld-find-code-refs/v2/options"
+)
+
+type word struct {
+	text   string
+	number bool
+	// start of a new separator-delimited token in the flag key
+	tokenStart bool
+}
+
+// splitWords splits a flag key into words at separators, changes in case, and between letters and numbers.
+// Numbers are attached to the preceding word in the same token unless numbers are split.
+func splitWords(flag string, numbers options.NumberCase) []string {
+	words := []word{}
+	runes := []rune(flag)
+	start := -1
+	tokenStart := true
+	flush := func(end int) {
+		if start >= 0 && end > start {
+			words = append(words, word{text: string(runes[start:end]), number: unicode.IsDigit(runes[start]), tokenStart: tokenStart})
+			tokenStart = false
+		}
+		start = -1
+	}
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush(i)
+			tokenStart = true
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+		prev := runes[i-1]
+		switch {
+		case unicode.IsDigit(prev) != unicode.IsDigit(r):
+			flush(i)
+			start = i
+		case unicode.IsLower(prev) && unicode.IsUpper(r):
+			flush(i)
+			start = i
+		case unicode.IsUpper(prev) && unicode.IsUpper(r) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			// the last capital of an uppercase run starts a new word, e.g. HTTPServer is HTTP Server
+			flush(i)
+			start = i
+		}
+	}
+	flush(len(runes))
+
+	ret := make([]string, 0, len(words))
+	for i, w := range words {
+		if numbers.Canonical() != options.SplitNumbers && w.number && i > 0 && !w.tokenStart {
+			ret[len(ret)-1] += w.text
+			continue
+		}
+		ret = append(ret, w.text)
+	}
+	return ret
+}
+
+// joinWords joins words according to a naming convention, writing configured acronyms in uppercase for camelCase and PascalCase
+func joinWords(t options.AliasType, words []string, acronyms []string) string {
+	acronymsByLowerWord := make(map[string]string, len(acronyms))
+	for _, acronym := range acronyms {
+		acronymsByLowerWord[strings.ToLower(acronym)] = acronym
+	}
+	capitalize := func(w string) string {
+		if acronym, ok := acronymsByLowerWord[strings.ToLower(w)]; ok {
+			return acronym
+		}
+		runes := []rune(strings.ToLower(w))
+		runes[0] = unicode.ToUpper(runes[0])
+		return string(runes)
+	}
+
+	transformed := make([]string, len(words))
+	separator := ""
+	for i, w := range words {
+		switch t.Canonical() {
+		case options.CamelCase:
+			if i == 0 {
+				transformed[i] = strings.ToLower(w)
+			} else {
+				transformed[i] = capitalize(w)
+			}
+		case options.PascalCase:
+			transformed[i] = capitalize(w)
+		case options.UpperSnakeCase:
+			transformed[i] = strings.ToUpper(w)
+		default:
+			transformed[i] = strings.ToLower(w)
+		}
+	}
+	switch t.Canonical() {
+	case options.SnakeCase, options.UpperSnakeCase:
+		separator = "_"
+	case options.KebabCase:
+		separator = "-"
+	case options.DotCase:
+		separator = "."
+	}
+	return strings.Join(transformed, separator)
+}
+
+// generateCaseOptionsAliases generates the naming convention aliases for a flag key configured by case options.
+// The first alias is the spelling described by the options, followed by any other variants.
+func generateCaseOptionsAliases(t options.AliasType, flag string, caseOptions options.CaseOptions) []string {
+	ret := []string{joinWords(t, splitWords(flag, caseOptions.Numbers), caseOptions.Acronyms)}
+	if !caseOptions.Variants {
+		return ret
+	}
+	for _, numbers := range []options.NumberCase{options.AttachNumbers, options.SplitNumbers} {
+		words := splitWords(flag, numbers)
+		ret = append(ret, joinWords(t, words, caseOptions.Acronyms), joinWords(t, words, nil))
+	}
+	if alias, err := GenerateNamingConventionAlias(options.Alias{Type: t}, flag); err == nil {
+		ret = append(ret, alias)
+	}
+	return helpers.Dedupe(ret)
+}
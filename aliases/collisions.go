@@ -0,0 +1,115 @@
+package aliases
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/helpers"
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/log"
+	"github.com/launchdarkly/ld-find-code-refs/v2/options"
+)
+
+const (
+	minAliasLen = 3 // Aliases shorter than this are likely to match unrelated code
+)
+
+// Identifiers that are common enough in source code that using them as an alias will produce false positives
+var commonIdentifiers = map[string]struct{}{
+	"client": {}, "config": {}, "context": {}, "data": {}, "default": {}, "disable": {}, "disabled": {},
+	"enable": {}, "enabled": {}, "false": {}, "feature": {}, "flag": {}, "flags": {}, "get": {},
+	"hide": {}, "key": {}, "list": {}, "mode": {}, "name": {}, "new": {}, "nil": {}, "none": {},
+	"null": {}, "off": {}, "old": {}, "options": {}, "result": {}, "set": {}, "settings": {}, "show": {},
+	"state": {}, "status": {}, "test": {}, "true": {}, "type": {}, "user": {}, "value": {}, "version": {},
+}
+
+// Collision describes an alias that was generated for more than one flag key, or that is identical to another flag key
+type Collision struct {
+	Alias    string
+	FlagKeys []string
+	Sources  []string
+}
+
+// FindCollisions returns every alias that would attribute a reference to more than one flag key
+func FindCollisions(flags []string, generated []GeneratedAlias) []Collision {
+	flagKeys := make(map[string]struct{}, len(flags))
+	for _, flag := range flags {
+		flagKeys[flag] = struct{}{}
+	}
+
+	collisionsByAlias := map[string]*Collision{}
+	aliases := []string{}
+	for _, g := range generated {
+		c, ok := collisionsByAlias[g.Alias]
+		if !ok {
+			c = &Collision{Alias: g.Alias}
+			collisionsByAlias[g.Alias] = c
+			aliases = append(aliases, g.Alias)
+		}
+		c.FlagKeys = append(c.FlagKeys, g.FlagKey)
+		c.Sources = append(c.Sources, g.Source)
+	}
+
+	ret := []Collision{}
+	for _, alias := range aliases {
+		c := collisionsByAlias[alias]
+		// an alias identical to a flag key will also match references to that flag
+		if _, ok := flagKeys[alias]; ok {
+			c.FlagKeys = append(c.FlagKeys, alias)
+		}
+		c.FlagKeys = helpers.Dedupe(c.FlagKeys)
+		c.Sources = helpers.Dedupe(c.Sources)
+		if len(c.FlagKeys) > 1 {
+			sort.Strings(c.FlagKeys)
+			ret = append(ret, *c)
+		}
+	}
+	return ret
+}
+
+// WeakAliasReason returns a description of why an alias is likely to produce false positives, or an empty string if it is not
+func WeakAliasReason(alias string) string {
+	if len(alias) < minAliasLen {
+		return fmt.Sprintf("shorter than %d characters", minAliasLen)
+	}
+	if _, ok := commonIdentifiers[strings.ToLower(alias)]; ok {
+		return "common identifier"
+	}
+	return ""
+}
+
+// ApplyCollisionPolicy handles colliding aliases according to the configured policy. Colliding aliases are removed
+// when the policy is `drop`, and an error is returned when the policy is `error`.
+func ApplyCollisionPolicy(policy options.AliasCollisionPolicy, flags []string, generated []GeneratedAlias) ([]GeneratedAlias, error) {
+	policy = policy.Canonical()
+	if policy == options.AllowCollisions || policy == "" {
+		return generated, nil
+	}
+
+	collisions := FindCollisions(flags, generated)
+	if len(collisions) == 0 {
+		return generated, nil
+	}
+
+	colliding := make(map[string]struct{}, len(collisions))
+	for _, c := range collisions {
+		colliding[c.Alias] = struct{}{}
+		log.Warning.Printf("alias '%s' matches multiple flags: %s", c.Alias, strings.Join(c.FlagKeys, ", "))
+	}
+
+	switch policy {
+	case options.ErrorCollisions:
+		return nil, fmt.Errorf("%d aliases match multiple flags, run the `aliases` command for details", len(collisions))
+	case options.DropCollisions:
+		ret := make([]GeneratedAlias, 0, len(generated))
+		for _, g := range generated {
+			if _, ok := colliding[g.Alias]; !ok {
+				ret = append(ret, g)
+			}
+		}
+		log.Info.Printf("dropped %d colliding aliases", len(collisions))
+		return ret, nil
+	}
+
+	return generated, nil
+}
@@ -0,0 +1,7 @@
+package auto
+
+const (
+	SomeFlag     = "someFlag"
+	AnotherFlag  = "anotherFlag"
+	notAFlagName = "not-a-flag"
+)
@@ -0,0 +1,2 @@
+// const COMMENTED_FLAG = 'someFlag'
+export const SOME_FLAG = 'someFlag';
@@ -1,4 +1,17 @@
 package aliases
 
+import "github.com/launchdarkly/ld-find-code-refs/v2/options"
+
 // Map of full file path to file contents
 type FileContentsMap = map[string][]byte
+
+// GeneratedAlias is a single alias generated for a flag key
+type GeneratedAlias struct {
+	FlagKey string
+	Alias   string
+	// Name of the alias configuration that generated the alias, or its index if no name was configured
+	Source string
+	Type   options.AliasType
+	// Paths or globs relative to the repository root where the alias may be used. The alias may be used in every file if empty.
+	Scope []string
+}
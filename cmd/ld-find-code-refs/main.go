@@ -1,7 +1,10 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -62,6 +65,228 @@ var extinctions = &cobra.Command{
 	},
 }
 
+var aliasesCmd = &cobra.Command{
+	Use:     "aliases",
+	Example: "ld-find-code-refs aliases --format csv --output aliases.csv",
+	Short:   "List the aliases generated for each project, and report aliases that match multiple flags or are likely to cause false positives",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := o.InitYAML()
+		if err != nil {
+			return err
+		}
+
+		opts, err := o.GetOptions()
+		if err != nil {
+			return err
+		}
+		err = opts.ValidateRequired()
+		if err != nil {
+			return err
+		}
+
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+
+		log.Init(opts.Debug)
+		return coderefs.Aliases(opts, format, output)
+	},
+}
+
+var validateCmd = &cobra.Command{
+	Use:     "validate",
+	Example: "ld-find-code-refs validate --dir .",
+	Short:   "Validate the .launchdarkly/coderefs.yaml configuration file, and report unknown fields and invalid values with their line numbers",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if printSchema, _ := cmd.Flags().GetBool("schema"); printSchema {
+			return o.WriteSchema(os.Stdout)
+		}
+
+		err := o.InitYAMLWithoutAccessToken()
+		if err != nil {
+			return err
+		}
+
+		configErrs := o.ValidateConfig()
+		for _, configErr := range configErrs {
+			fmt.Fprintln(os.Stderr, configErr)
+		}
+		if len(configErrs) > 0 {
+			return fmt.Errorf("found %d problem(s) in configuration", len(configErrs))
+		}
+		if configFiles := o.ConfigFiles(); len(configFiles) > 0 {
+			fmt.Printf("configuration is valid: %s\n", strings.Join(configFiles, " -> "))
+			return nil
+		}
+		fmt.Println("configuration is valid")
+		return nil
+	},
+}
+
+var initCmd = &cobra.Command{
+	Use:     "init",
+	Example: "ld-find-code-refs init --dir . --projKey my-project",
+	Short:   "Write a .launchdarkly/coderefs.yaml file with the aliases and delimiters that match the project's flag keys in the repository",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := o.InitYAML()
+		if err != nil {
+			return err
+		}
+
+		opts, err := o.GetOptions()
+		if err != nil {
+			return err
+		}
+
+		force, _ := cmd.Flags().GetBool("force")
+
+		log.Init(opts.Debug)
+		return coderefs.Init(opts, force)
+	},
+}
+
+var doctorCmd = &cobra.Command{
+	Use:     "doctor",
+	Example: "ld-find-code-refs doctor --dir . --repoName my-repo --projKey my-project",
+	Short:   "Check the access token, projects, repository, and git checkout without scanning, and print a checklist with hints to fix failed checks",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := o.InitYAML()
+		if err != nil {
+			return err
+		}
+
+		opts, err := o.GetOptions()
+		if err != nil {
+			return err
+		}
+
+		checkWrite, _ := cmd.Flags().GetBool("checkWrite")
+
+		log.Init(opts.Debug)
+		return coderefs.Doctor(opts, checkWrite, os.Stdout)
+	},
+}
+
+// repositoryOptions reads the options of the repos and branches commands, which only require an access token
+func repositoryOptions() o.Options {
+	opts := o.GetAPIOptions()
+	log.Init(opts.Debug)
+	return opts
+}
+
+// repositoryName returns the repository name argument, or the repoName option when no argument is given
+func repositoryName(args []string, opts o.Options) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	if opts.RepoName == "" {
+		return "", errors.New("missing repository name: pass it as an argument or set the repoName option")
+	}
+	return opts.RepoName, nil
+}
+
+var reposCmd = &cobra.Command{
+	Use:   "repos",
+	Short: "Inspect and manage the code reference repositories stored in LaunchDarkly",
+}
+
+var reposListCmd = &cobra.Command{
+	Use:     "list",
+	Example: "ld-find-code-refs repos list --format json",
+	Short:   "List the code reference repositories stored in LaunchDarkly",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := repositoryOptions()
+
+		format, _ := cmd.Flags().GetString("format")
+		return coderefs.ListRepositories(opts, format, os.Stdout)
+	},
+}
+
+var reposGetCmd = &cobra.Command{
+	Use:     "get [repository]",
+	Example: "ld-find-code-refs repos get my-repo",
+	Short:   "Show the settings of a code reference repository. Defaults to the repoName option",
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := repositoryOptions()
+		name, err := repositoryName(args, opts)
+		if err != nil {
+			return err
+		}
+
+		format, _ := cmd.Flags().GetString("format")
+		return coderefs.GetRepository(opts, name, format, os.Stdout)
+	},
+}
+
+var reposEnableCmd = &cobra.Command{
+	Use:     "enable [repository]",
+	Example: "ld-find-code-refs repos enable my-repo",
+	Short:   "Enable a code reference repository, so scans can update it. Defaults to the repoName option",
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := repositoryOptions()
+		name, err := repositoryName(args, opts)
+		if err != nil {
+			return err
+		}
+
+		return coderefs.SetRepositoryEnabled(opts, name, true)
+	},
+}
+
+var reposDisableCmd = &cobra.Command{
+	Use:     "disable [repository]",
+	Example: "ld-find-code-refs repos disable my-repo",
+	Short:   "Disable a code reference repository, so scans fail instead of updating it. Defaults to the repoName option",
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := repositoryOptions()
+		name, err := repositoryName(args, opts)
+		if err != nil {
+			return err
+		}
+
+		return coderefs.SetRepositoryEnabled(opts, name, false)
+	},
+}
+
+var reposDeleteCmd = &cobra.Command{
+	Use:     "delete repository",
+	Example: "ld-find-code-refs repos delete my-old-repo --yes",
+	Short:   "Delete a code reference repository and the code references of all of its branches from LaunchDarkly",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+			return fmt.Errorf("deleting repository %q cannot be undone, pass --yes to confirm", args[0])
+		}
+
+		return coderefs.DeleteRepository(repositoryOptions(), args[0])
+	},
+}
+
+var branchesCmd = &cobra.Command{
+	Use:   "branches",
+	Short: "Inspect the branches of a code reference repository stored in LaunchDarkly",
+}
+
+var branchesListCmd = &cobra.Command{
+	Use:     "list [repository]",
+	Example: "ld-find-code-refs branches list my-repo --format json",
+	Short:   "List the branches of a code reference repository with their head, sync time, commit time, and reference counts. Defaults to the repoName option",
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := repositoryOptions()
+		name, err := repositoryName(args, opts)
+		if err != nil {
+			return err
+		}
+
+		format, _ := cmd.Flags().GetString("format")
+		return coderefs.ListBranches(opts, name, format, os.Stdout)
+	},
+}
+
 var cmd = &cobra.Command{
 	Use: "ld-find-code-refs",
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -93,6 +318,28 @@ func main() {
 	cmd.AddCommand(prune)
 	cmd.AddCommand(extinctions)
 
+	aliasesCmd.Flags().String("format", "table", "Output format for the alias report. Acceptable values: table|csv|json.")
+	aliasesCmd.Flags().String("output", "", "If provided, the alias report will be written to this file instead of stdout.")
+	cmd.AddCommand(aliasesCmd)
+
+	validateCmd.Flags().Bool("schema", false, "Print the JSON Schema of the configuration file instead of validating it.")
+	cmd.AddCommand(validateCmd)
+
+	initCmd.Flags().Bool("force", false, "Replace an existing .launchdarkly/coderefs.yaml file.")
+	cmd.AddCommand(initCmd)
+
+	doctorCmd.Flags().Bool("checkWrite", false, "Check that the access token can update code references by sending an update of each existing repository that changes nothing.")
+	cmd.AddCommand(doctorCmd)
+
+	for _, c := range []*cobra.Command{reposListCmd, reposGetCmd, branchesListCmd} {
+		c.Flags().String("format", "table", "Output format. Acceptable values: table|json.")
+	}
+	reposDeleteCmd.Flags().Bool("yes", false, "Confirm that the repository and its code references are deleted.")
+	reposCmd.AddCommand(reposListCmd, reposGetCmd, reposEnableCmd, reposDisableCmd, reposDeleteCmd)
+	cmd.AddCommand(reposCmd)
+	branchesCmd.AddCommand(branchesListCmd)
+	cmd.AddCommand(branchesCmd)
+
 	if err := cmd.Execute(); err != nil {
 		os.Exit(1)
 	}
@@ -0,0 +1,130 @@
+package coderefs
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/launchdarkly/ld-find-code-refs/v2/aliases"
+	"github.com/launchdarkly/ld-find-code-refs/v2/flags"
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/log"
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/validation"
+	"github.com/launchdarkly/ld-find-code-refs/v2/options"
+)
+
+type aliasReportRow struct {
+	ProjKey string   `json:"projKey"`
+	FlagKey string   `json:"flagKey"`
+	Alias   string   `json:"alias"`
+	Source  string   `json:"source"`
+	Type    string   `json:"type"`
+	Issues  []string `json:"issues,omitempty"`
+}
+
+func (r aliasReportRow) toRecord() []string {
+	return []string{r.ProjKey, r.FlagKey, r.Alias, r.Source, r.Type, strings.Join(r.Issues, "; ")}
+}
+
+var aliasReportHeader = []string{"projKey", "flagKey", "alias", "source", "type", "issues"}
+
+// Aliases generates aliases for every configured project and writes each generated alias, along with any
+// collisions or likely false positives, to the provided output path. Output is written to stdout if path is empty.
+func Aliases(opts options.Options, format, path string) error {
+	if len(opts.ProjKey) > 0 {
+		opts.Projects = append(opts.Projects, options.Project{
+			Key: opts.ProjKey,
+		})
+	}
+	absPath, err := validation.NormalizeAndValidatePath(opts.Dir)
+	if err != nil {
+		return fmt.Errorf("could not validate directory option: %w", err)
+	}
+
+	flagKeys := flags.ListFlagKeys(opts)
+
+	rows := []aliasReportRow{}
+	totalCollisions := 0
+	for _, project := range opts.Projects {
+		projectFlags := flagKeys[project.Key]
+		projectAliases := opts.ProjectAliases(project)
+		generated, err := aliases.GenerateAliasList(projectFlags, projectAliases, absPath)
+		if err != nil {
+			return fmt.Errorf("failed to generate aliases: %w for project: %s", err, project.Key)
+		}
+
+		collisions := aliases.FindCollisions(projectFlags, generated)
+		totalCollisions += len(collisions)
+		collisionsByAlias := make(map[string]aliases.Collision, len(collisions))
+		for _, c := range collisions {
+			collisionsByAlias[c.Alias] = c
+		}
+
+		for _, g := range generated {
+			row := aliasReportRow{ProjKey: project.Key, FlagKey: g.FlagKey, Alias: g.Alias, Source: g.Source, Type: g.Type.String()}
+			if c, ok := collisionsByAlias[g.Alias]; ok {
+				row.Issues = append(row.Issues, "collides with flags: "+strings.Join(otherFlagKeys(c.FlagKeys, g.FlagKey), ", "))
+			}
+			if reason := aliases.WeakAliasReason(g.Alias); reason != "" {
+				row.Issues = append(row.Issues, reason)
+			}
+			rows = append(rows, row)
+		}
+	}
+	log.Info.Printf("generated %d aliases with %d collisions across %d projects", len(rows), totalCollisions, len(opts.Projects))
+
+	w := io.Writer(os.Stdout)
+	if path != "" {
+		/* #nosec */
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return writeAliasReport(w, format, rows)
+}
+
+func writeAliasReport(w io.Writer, format string, rows []aliasReportRow) error {
+	switch strings.ToLower(format) {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	case "csv":
+		cw := csv.NewWriter(w)
+		records := make([][]string, 0, len(rows)+1)
+		records = append(records, aliasReportHeader)
+		for _, r := range rows {
+			records = append(records, r.toRecord())
+		}
+		return cw.WriteAll(records)
+	case "", "table":
+		table := tablewriter.NewWriter(w)
+		table.Header(aliasReportHeader)
+		for _, r := range rows {
+			if err := table.Append(r.toRecord()); err != nil {
+				return err
+			}
+		}
+		return table.Render()
+	default:
+		return fmt.Errorf(`invalid value %q for "format": must be table, csv, or json`, format)
+	}
+}
+
+func otherFlagKeys(flagKeys []string, flagKey string) []string {
+	ret := make([]string, 0, len(flagKeys))
+	for _, k := range flagKeys {
+		if k != flagKey {
+			ret = append(ret, k)
+		}
+	}
+	return ret
+}
@@ -14,17 +14,16 @@ import (
 )
 
 func Run(opts options.Options, output bool) {
-	if len(opts.ProjKey) > 0 {
-		opts.Projects = append(opts.Projects, options.Project{
-			Key: opts.ProjKey,
-		})
-	}
+	opts = withProjKey(opts)
 	absPath, err := validation.NormalizeAndValidatePath(opts.Dir)
 	if err != nil {
 		log.Error.Fatalf("could not validate directory option: %s", err)
 	}
 
 	log.Info.Printf("absolute directory path: %s", absPath)
+	if configFiles := options.ConfigFiles(); len(configFiles) > 0 {
+		log.Debug.Printf("configuration files, from highest to lowest precedence: %s", strings.Join(configFiles, " -> "))
+	}
 	ldApi := ld.InitApiClient(ld.ApiOptions{ApiKey: opts.AccessToken, BaseUri: opts.BaseUri, UserAgent: helpers.GetUserAgent(opts.UserAgent)})
 
 	branchName := opts.Branch
@@ -41,7 +40,53 @@ func Run(opts options.Options, output bool) {
 		commitTime = gitClient.GitTimestamp
 	}
 
-	repoParams := ld.RepoParams{
+	var updateId *int
+	if opts.UpdateSequenceId >= 0 {
+		updateIdOption := opts.UpdateSequenceId
+		updateId = &updateIdOption
+	}
+
+	newBranch := func(refs []ld.ReferenceHunksRep) ld.BranchRep {
+		return ld.BranchRep{
+			Name:             strings.TrimPrefix(branchName, "refs/heads/"),
+			Head:             revision,
+			UpdateSequenceId: updateId,
+			SyncTime:         helpers.MakeTimestamp(),
+			References:       refs,
+			CommitTime:       commitTime,
+		}
+	}
+
+	if opts.Discover {
+		configs := discoverConfigs(opts, absPath)
+		repoParams := make([]ld.RepoParams, 0, len(configs))
+		for _, config := range configs {
+			repoParams = append(repoParams, getRepoParams(config))
+		}
+		matchers, refs := search.ScanDiscovered(configs, repoParams, absPath)
+		for i, config := range configs {
+			log.Info.Printf("found %d files with code references for repository %s in directory: %s", len(refs[i]), config.RepoName, displayDir(config.Subdirectory))
+			publishRepositories(config, output, matchers[i], refs[i], newBranch, gitClient, ldApi)
+		}
+		return
+	}
+
+	matcher, refs := search.Scan(opts, getRepoParams(opts), absPath)
+	publishRepositories(opts, output, matcher, refs, newBranch, gitClient, ldApi)
+}
+
+// withProjKey adds the projKey option to the configured projects
+func withProjKey(opts options.Options) options.Options {
+	if len(opts.ProjKey) > 0 {
+		opts.Projects = append(opts.Projects, options.Project{
+			Key: opts.ProjKey,
+		})
+	}
+	return opts
+}
+
+func getRepoParams(opts options.Options) ld.RepoParams {
+	return ld.RepoParams{
 		Type:              opts.RepoType,
 		Name:              opts.RepoName,
 		Url:               opts.RepoUrl,
@@ -49,24 +94,53 @@ func Run(opts options.Options, output bool) {
 		HunkUrlTemplate:   opts.HunkUrlTemplate,
 		DefaultBranch:     opts.DefaultBranch,
 	}
+}
 
-	matcher, refs := search.Scan(opts, repoParams, absPath)
+// publishRepositories sends the references in the paths of each configured repository to that repository, and all other references
+// to the top-level repository. Files in the paths of more than one repository belong to the first.
+func publishRepositories(opts options.Options, output bool, matcher search.Matcher, refs []ld.ReferenceHunksRep, newBranch func([]ld.ReferenceHunksRep) ld.BranchRep, gitClient *git.Client, ldApi ld.ApiClient) {
+	if len(opts.Repositories) == 0 {
+		publish(opts, output, matcher, newBranch(refs), getRepoParams(opts), gitClient, ldApi)
+		return
+	}
 
-	var updateId *int
-	if opts.UpdateSequenceId >= 0 {
-		updateIdOption := opts.UpdateSequenceId
-		updateId = &updateIdOption
+	refsByRepository := partitionReferences(opts.Repositories, refs)
+	otherRepositoryPaths := []string{}
+	for i, r := range opts.Repositories {
+		repoOpts := opts.WithRepository(r)
+		repoParams := getRepoParams(repoOpts)
+		if !opts.DryRun {
+			if err := ldApi.MaybeUpsertCodeReferenceRepository(repoParams); err != nil {
+				helpers.FatalServiceError(err, opts.IgnoreServiceErrors)
+			}
+		}
+		log.Info.Printf("found %d files with code references for repository: %s", len(refsByRepository[i]), r.Name)
+		publish(repoOpts, output, matcher.ForRepository(r.Paths, otherRepositoryPaths), newBranch(refsByRepository[i]), repoParams, gitClient, ldApi)
+		otherRepositoryPaths = append(otherRepositoryPaths, r.Paths...)
 	}
+	publish(opts, output, matcher.ForRepository(nil, otherRepositoryPaths), newBranch(refsByRepository[len(opts.Repositories)]), getRepoParams(opts), gitClient, ldApi)
+}
 
-	branch := ld.BranchRep{
-		Name:             strings.TrimPrefix(branchName, "refs/heads/"),
-		Head:             revision,
-		UpdateSequenceId: updateId,
-		SyncTime:         helpers.MakeTimestamp(),
-		References:       refs,
-		CommitTime:       commitTime,
+// partitionReferences returns the references in the paths of each repository, followed by the references not in any repository
+func partitionReferences(repositories []options.Repository, refs []ld.ReferenceHunksRep) [][]ld.ReferenceHunksRep {
+	refsByRepository := make([][]ld.ReferenceHunksRep, len(repositories)+1)
+	for i := range refsByRepository {
+		refsByRepository[i] = []ld.ReferenceHunksRep{}
+	}
+	for _, ref := range refs {
+		i := len(repositories)
+		for j, r := range repositories {
+			if search.MatchesPaths(r.Paths, ref.Path) {
+				i = j
+				break
+			}
+		}
+		refsByRepository[i] = append(refsByRepository[i], ref)
 	}
+	return refsByRepository
+}
 
+func publish(opts options.Options, output bool, matcher search.Matcher, branch ld.BranchRep, repoParams ld.RepoParams, gitClient *git.Client, ldApi ld.ApiClient) {
 	if output {
 		generateHunkOutput(opts, matcher, branch, repoParams, ldApi)
 	}
@@ -76,6 +150,40 @@ func Run(opts options.Options, output bool) {
 	}
 }
 
+// discoverConfigs returns the root options followed by the options of each subdirectory with a .launchdarkly/coderefs.yaml file
+func discoverConfigs(opts options.Options, absPath string) []options.Options {
+	subdirectories, err := search.DiscoverConfigDirectories(absPath)
+	if err != nil {
+		log.Error.Fatalf("could not discover configuration files: %s", err)
+	}
+	configs := []options.Options{opts}
+	repoNames := map[string]string{opts.RepoName: ""}
+	for _, subdirectory := range subdirectories {
+		config, err := opts.ForSubdirectory(subdirectory)
+		if err != nil {
+			log.Error.Fatalf("could not read configuration for directory %s: %s", subdirectory, err)
+		}
+		config = withProjKey(config)
+		if err := config.Validate(); err != nil {
+			log.Error.Fatalf("invalid configuration for directory %s: %s", subdirectory, err)
+		}
+		if dir, ok := repoNames[config.RepoName]; ok {
+			log.Error.Fatalf("directories %s and %s have the same repoName %q: each configuration file must set a different repoName", displayDir(dir), subdirectory, config.RepoName)
+		}
+		repoNames[config.RepoName] = subdirectory
+		configs = append(configs, config)
+	}
+	log.Info.Printf("discovered %d configuration files in subdirectories", len(subdirectories))
+	return configs
+}
+
+func displayDir(subdirectory string) string {
+	if subdirectory == "" {
+		return "."
+	}
+	return subdirectory
+}
+
 func Prune(opts options.Options, branches []string) {
 	ldApi := ld.InitApiClient(ld.ApiOptions{ApiKey: opts.AccessToken, BaseUri: opts.BaseUri, UserAgent: helpers.GetUserAgent(opts.UserAgent)})
 	err := ldApi.PostDeleteBranchesTask(opts.RepoName, branches)
@@ -169,7 +277,13 @@ func runExtinctions(opts options.Options, matcher search.Matcher, branch ld.Bran
 	if opts.Lookback > 0 {
 		var removedFlags []ld.ExtinctionRep
 
-		flagCounts := branch.CountByProjectAndFlag(matcher.GetElements(), opts.GetProjectKeys())
+		// low-confidence references through key templates don't keep flags from being removed
+		counted := branch.WithoutConfidence(ld.LowConfidence)
+		if options.CommentPolicy(opts.Comments).Canonical() == options.TagComments {
+			// flags only referenced in comments may be extinct
+			counted = counted.WithoutKind(ld.CommentKind)
+		}
+		flagCounts := counted.CountByProjectAndFlag(matcher.GetElements(), opts.GetProjectKeys())
 		for _, project := range opts.Projects {
 			missingFlags := []string{}
 			for flag, count := range flagCounts[project.Key] {
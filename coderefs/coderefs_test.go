@@ -1,13 +1,19 @@
 package coderefs
 
 import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/launchdarkly/ld-find-code-refs/v2/internal/ld"
 	"github.com/launchdarkly/ld-find-code-refs/v2/internal/log"
+	"github.com/launchdarkly/ld-find-code-refs/v2/options"
+	"github.com/launchdarkly/ld-find-code-refs/v2/search"
 )
 
 func init() {
@@ -54,3 +60,168 @@ func Test_calculateStaleBranches(t *testing.T) {
 		})
 	}
 }
+
+func Test_partitionReferences(t *testing.T) {
+	repositories := []options.Repository{
+		{Name: "api", Paths: []string{"services/api"}},
+		{Name: "services", Paths: []string{"services/*"}},
+	}
+	refs := []ld.ReferenceHunksRep{
+		{Path: "services/api/main.go"},
+		{Path: "services/web/app.js"},
+		{Path: "services/api-gateway/main.go"},
+		{Path: "README.md"},
+	}
+
+	assert.Equal(t, [][]ld.ReferenceHunksRep{
+		{{Path: "services/api/main.go"}},
+		{{Path: "services/web/app.js"}, {Path: "services/api-gateway/main.go"}},
+		{{Path: "README.md"}},
+	}, partitionReferences(repositories, refs))
+}
+
+func Test_suggestConfig(t *testing.T) {
+	candidates := namingConventionCandidates([]string{"new-checkout", "dark-mode"})
+	assert.Equal(t, []options.AliasType{options.CamelCase}, candidates["newCheckout"])
+	assert.NotContains(t, candidates, "new-checkout", "aliases that are flag keys are not candidates")
+
+	survey := search.Survey{
+		Files:           map[string]int{"go": 12, "javascript": 1},
+		EvaluationFiles: map[string]int{"go": 3},
+		Candidates:      map[string]int{"newCheckout": 2, "darkMode": 1, "NEW_CHECKOUT": 1},
+		Delimiters: map[options.DelimiterPair]int{
+			{Left: `"`, Right: `"`}: 10,
+			{Left: "<", Right: "<"}: 1,
+			{Left: "{", Right: "}"}: 4,
+		},
+		ProjectDirs: []string{"services/api", "web"},
+	}
+
+	assert.Equal(t, `# Generated by `+"`ld-find-code-refs init`"+`. See https://github.com/launchdarkly/ld-find-code-refs/blob/main/docs/CONFIGURATION.md
+# yaml-language-server: $schema=https://raw.githubusercontent.com/launchdarkly/ld-find-code-refs/main/docs/coderefs.schema.json
+
+# Languages: go (12 files), javascript (1 file)
+# LaunchDarkly and OpenFeature SDK evaluation calls: go (3 files)
+
+projKey: "default"
+repoName: "monorepo"
+
+# Naming conventions of flag keys found in the repository. See docs/ALIASES.md for other types of aliases.
+aliases:
+  - type: camelcase # 3 matches, e.g. darkMode
+
+# Characters found around flag keys, in addition to quotes. Remove any that are not part of references.
+delimiters:
+  pairs:
+    - left: "{" # 4 matches
+      right: "}"
+
+# Directories of packages or services found in the repository. To search for a different LaunchDarkly
+# project in each directory, replace projKey with projects and set the key of each project.
+# projects:
+#   - key: default
+#     dir: services/api
+#   - key: default
+#     dir: web
+`, suggestConfig("default", "monorepo", survey, candidates))
+}
+
+func Test_runChecks(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		switch req.Method + " " + req.URL.Path {
+		case "GET /api/v2/projects/default":
+			res.WriteHeader(http.StatusOK)
+		case "GET /api/v2/code-refs/repositories/web":
+			_, _ = res.Write([]byte(`{"name":"web","type":"custom","enabled":true}`))
+		case "PATCH /api/v2/code-refs/repositories/web":
+			res.WriteHeader(http.StatusForbidden)
+		case "GET /api/v2/code-refs/repositories/mobile":
+			_, _ = res.Write([]byte(`{"name":"mobile","type":"custom","enabled":false}`))
+		default:
+			res.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	retryMax := 0
+	ldApi := ld.InitApiClient(ld.ApiOptions{ApiKey: "api-x", BaseUri: testServer.URL, RetryMax: &retryMax})
+	noGit := func(options.Options, string) []checkResult { return nil }
+	opts := options.Options{
+		AccessToken: "api-x",
+		Dir:         t.TempDir(),
+		RepoName:    "web",
+		RepoType:    "custom",
+		Projects:    []options.Project{{Key: "default"}, {Key: "missing"}},
+		Repositories: []options.Repository{
+			{Name: "mobile", Paths: []string{"mobile"}},
+			{Name: "docs", Paths: []string{"docs"}},
+		},
+	}
+
+	statuses := func(results []checkResult) map[string]checkStatus {
+		statuses := map[string]checkStatus{}
+		for _, result := range results {
+			statuses[result.name] = result.status
+		}
+		return statuses
+	}
+	results := runChecks(opts, ldApi, true, noGit)
+	assert.Equal(t, map[string]checkStatus{
+		"configuration is valid":                                       checkPassed,
+		"LaunchDarkly access token is valid":                           checkPassed,
+		`project "default" exists`:                                     checkPassed,
+		`project "missing" exists`:                                     checkFailed,
+		`repository "web" is enabled`:                                  checkPassed,
+		`access token can update code references of repository "web"`:  checkFailed,
+		`repository "mobile" is enabled`:                               checkFailed,
+		`repository "docs" is enabled`:                                 checkSkipped,
+		`access token can update code references of repository "docs"`: checkSkipped,
+	}, statuses(results))
+	assert.Equal(t, checkSkipped, statuses(runChecks(opts, ldApi, false, noGit))[`access token can update code references of repository "web"`])
+
+	var b bytes.Buffer
+	writeChecklist(&b, results[3:4])
+	assert.Equal(t, "FAIL  project \"missing\" exists: not found\n      hint: check --projKey or the keys of projects. Project keys are listed under Account settings > Projects\n", b.String())
+
+	opts.RepoName = ""
+	results = runChecks(opts, ldApi, true, noGit)
+	assert.Len(t, results, 1)
+	assert.Equal(t, checkFailed, results[0].status)
+}
+
+func Test_ListBranches(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/api/v2/code-refs/repositories/web/branches", req.URL.Path)
+		_, _ = res.Write([]byte(`{"items":[{"name":"main","head":"abc123","syncTime":1700000000000,"commitTime":1699990000000,
+			"references":[{"path":"a.go","hunks":[{"flagKey":"flag1"},{"flagKey":"flag2"}]},{"path":"b.go","hunks":[{"flagKey":"flag1"}]}]},
+			{"name":"feature","head":"def456","syncTime":1700000000000}]}`))
+	}))
+	defer testServer.Close()
+	opts := options.Options{AccessToken: "api-x", BaseUri: testServer.URL}
+
+	var b bytes.Buffer
+	require.NoError(t, ListBranches(opts, "web", "json", &b))
+	assert.JSONEq(t, `[
+		{"name":"main","head":"abc123","syncTime":"2023-11-14T22:13:20Z","commitTime":"2023-11-14T19:26:40Z","files":2,"references":3},
+		{"name":"feature","head":"def456","syncTime":"2023-11-14T22:13:20Z","files":0,"references":0}
+	]`, b.String())
+
+	b.Reset()
+	require.NoError(t, ListBranches(opts, "web", "table", &b))
+	assert.Contains(t, b.String(), "abc123")
+
+	assert.EqualError(t, ListBranches(opts, "web", "csv", &b), `invalid value "csv" for "format": must be table or json`)
+	assert.EqualError(t, ListBranches(options.Options{}, "web", "table", &b), "missing required option(s): [accessToken]")
+}
+
+func Test_writeRepositories(t *testing.T) {
+	rows := []repositoryRow{newRepositoryRow(ld.RepoRep{Name: "web", Type: "github", Url: "https://github.com/org/web", DefaultBranch: "main"})}
+
+	var b bytes.Buffer
+	require.NoError(t, writeRepositories(&b, "json", rows))
+	assert.JSONEq(t, `[{"name":"web","type":"github","sourceLink":"https://github.com/org/web","defaultBranch":"main","enabled":false}]`, b.String())
+
+	b.Reset()
+	require.NoError(t, writeRepositories(&b, "table", rows))
+	assert.Contains(t, b.String(), "https://github.com/org/web")
+}
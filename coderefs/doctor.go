@@ -0,0 +1,205 @@
+package coderefs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/git"
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/helpers"
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/ld"
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/validation"
+	"github.com/launchdarkly/ld-find-code-refs/v2/options"
+)
+
+type checkStatus string
+
+const (
+	checkPassed  checkStatus = "PASS"
+	checkFailed  checkStatus = "FAIL"
+	checkSkipped checkStatus = "SKIP"
+)
+
+// checkResult is an item of the doctor checklist
+type checkResult struct {
+	status checkStatus
+	name   string
+	// Reason for the status, such as the error of a failed check
+	detail string
+	// How to fix a failed check
+	hint string
+}
+
+func passed(name string) checkResult {
+	return checkResult{status: checkPassed, name: name}
+}
+
+func failed(name string, err error, hint string) checkResult {
+	return checkResult{status: checkFailed, name: name, detail: err.Error(), hint: hint}
+}
+
+func skipped(name, reason string) checkResult {
+	return checkResult{status: checkSkipped, name: name, detail: reason}
+}
+
+// Doctor checks the configuration, the LaunchDarkly access token, projects and repository, and the git checkout, without scanning
+// the repository, and writes a checklist of the results to w. If checkWrite is true, the access token is checked by sending an
+// update of each existing repository that changes nothing.
+func Doctor(opts options.Options, checkWrite bool, w io.Writer) error {
+	opts = withProjKey(opts)
+	ldApi := ld.InitApiClient(ld.ApiOptions{ApiKey: opts.AccessToken, BaseUri: opts.BaseUri, UserAgent: helpers.GetUserAgent(opts.UserAgent)})
+	results := runChecks(opts, ldApi, checkWrite, checkGit)
+	writeChecklist(w, results)
+
+	failures := 0
+	for _, result := range results {
+		if result.status == checkFailed {
+			failures++
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d check(s) failed", failures)
+	}
+	return nil
+}
+
+func runChecks(opts options.Options, ldApi ld.ApiClient, checkWrite bool, checkGit func(options.Options, string) []checkResult) []checkResult {
+	const configName = "configuration is valid"
+	if err := opts.Validate(); err != nil {
+		return []checkResult{failed(configName, err, "run `ld-find-code-refs validate` and see docs/CONFIGURATION.md")}
+	}
+	absPath, err := validation.NormalizeAndValidatePath(opts.Dir)
+	if err != nil {
+		return []checkResult{failed(configName, fmt.Errorf("could not validate directory option: %w", err), "set --dir to the root of the repository checkout")}
+	}
+	results := []checkResult{passed(configName)}
+	results = append(results, checkLaunchDarkly(opts, ldApi, checkWrite)...)
+	return append(results, checkGit(opts, absPath)...)
+}
+
+func checkLaunchDarkly(opts options.Options, ldApi ld.ApiClient, checkWrite bool) []checkResult {
+	const tokenName = "LaunchDarkly access token is valid"
+	results := []checkResult{}
+
+	for i, project := range opts.Projects {
+		name := fmt.Sprintf("project %q exists", project.Key)
+		err := ldApi.GetProject(project.Key)
+		switch {
+		case i == 0 && errors.Is(err, ld.UnauthorizedErr):
+			return []checkResult{failed(tokenName, err, "set --accessToken or LD_ACCESS_TOKEN to an API access token of your LaunchDarkly account, not an SDK key")}
+		case i == 0 && err == nil:
+			results = append(results, passed(tokenName))
+		case i == 0:
+			results = append(results, skipped(tokenName, "could not read the first project"))
+		}
+		switch {
+		case err == nil:
+			results = append(results, passed(name))
+		case errors.Is(err, ld.NotFoundErr):
+			results = append(results, failed(name, err, "check --projKey or the keys of projects. Project keys are listed under Account settings > Projects"))
+		case errors.Is(err, ld.ForbiddenErr):
+			results = append(results, failed(name, err, "use an access token with a role that can read this project"))
+		default:
+			results = append(results, failed(name, err, "check --baseUri and the network connection to LaunchDarkly"))
+		}
+	}
+
+	repoNames := []string{opts.RepoName}
+	for _, r := range opts.Repositories {
+		repoNames = append(repoNames, r.Name)
+	}
+	for _, repoName := range repoNames {
+		results = append(results, checkRepository(repoName, opts.DryRun, checkWrite, ldApi)...)
+	}
+	return results
+}
+
+func checkRepository(repoName string, dryRun, checkWrite bool, ldApi ld.ApiClient) []checkResult {
+	enabledName := fmt.Sprintf("repository %q is enabled", repoName)
+	writeName := fmt.Sprintf("access token can update code references of repository %q", repoName)
+
+	repo, err := ldApi.GetCodeReferenceRepository(repoName)
+	switch {
+	case errors.Is(err, ld.NotFoundErr):
+		return []checkResult{
+			skipped(enabledName, "the repository does not exist yet, and will be created by the first scan"),
+			skipped(writeName, "the repository does not exist yet"),
+		}
+	case errors.Is(err, ld.ForbiddenErr):
+		return []checkResult{failed(enabledName, err, "use an access token with a role that can read code references")}
+	case err != nil:
+		return []checkResult{failed(enabledName, err, "check --baseUri and the network connection to LaunchDarkly")}
+	case !repo.Enabled:
+		return []checkResult{failed(enabledName, ld.RepositoryDisabledErr, "enable the repository in the code references settings of LaunchDarkly, or change repoName")}
+	}
+	results := []checkResult{passed(enabledName)}
+
+	if dryRun {
+		return append(results, skipped(writeName, "dryRun is enabled"))
+	}
+	if !checkWrite {
+		return append(results, skipped(writeName, "--checkWrite is not set"))
+	}
+	err = ldApi.CheckCodeReferenceRepositoryUpdate(ld.RepoParams{
+		Name:              repo.Name,
+		Type:              repo.Type,
+		Url:               repo.Url,
+		CommitUrlTemplate: repo.CommitUrlTemplate,
+		HunkUrlTemplate:   repo.HunkUrlTemplate,
+		DefaultBranch:     repo.DefaultBranch,
+	})
+	switch {
+	case err == nil:
+		results = append(results, passed(writeName))
+	case errors.Is(err, ld.ForbiddenErr):
+		results = append(results, failed(writeName, err, "use an access token with a role that can update code references, such as Writer"))
+	default:
+		results = append(results, failed(writeName, err, "check --baseUri and the network connection to LaunchDarkly"))
+	}
+	return results
+}
+
+func checkGit(opts options.Options, absPath string) []checkResult {
+	const refName = "git branch is checked out"
+	const historyName = "git history covers the lookback"
+	if opts.Revision != "" {
+		return []checkResult{skipped(refName, "revision is set"), skipped(historyName, "revision is set")}
+	}
+
+	gitClient, err := git.NewClient(absPath, opts.Branch, opts.AllowTags)
+	if err != nil {
+		return []checkResult{
+			failed(refName, err, "check out a branch, or set --branch when CI checks out a detached HEAD"),
+			skipped(historyName, "the git checkout could not be read"),
+		}
+	}
+	results := []checkResult{passed(refName)}
+
+	if opts.Lookback == 0 {
+		return append(results, skipped(historyName, "lookback is 0"))
+	}
+	shallow, err := gitClient.IsShallow()
+	switch {
+	case err != nil:
+		results = append(results, failed(historyName, err, "check that --dir is a git checkout"))
+	case shallow:
+		results = append(results, failed(historyName, fmt.Errorf("shallow clone: commits within the lookback of %d may be missing, so removed flags may not be found", opts.Lookback),
+			"fetch the full history, e.g. `fetch-depth: 0` with actions/checkout or `git fetch --unshallow`, or set --lookback 0"))
+	default:
+		results = append(results, passed(historyName))
+	}
+	return results
+}
+
+func writeChecklist(w io.Writer, results []checkResult) {
+	for _, result := range results {
+		if result.detail != "" {
+			fmt.Fprintf(w, "%s  %s: %s\n", result.status, result.name, result.detail)
+		} else {
+			fmt.Fprintf(w, "%s  %s\n", result.status, result.name)
+		}
+		if result.hint != "" {
+			fmt.Fprintf(w, "      hint: %s\n", result.hint)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package coderefs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/launchdarkly/ld-find-code-refs/v2/aliases"
+	"github.com/launchdarkly/ld-find-code-refs/v2/flags"
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/log"
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/validation"
+	"github.com/launchdarkly/ld-find-code-refs/v2/options"
+	"github.com/launchdarkly/ld-find-code-refs/v2/search"
+)
+
+// Minimum number of matches for an alias type or delimiter to be suggested, so a single coincidental match is not enough
+const minSuggestionMatches = 2
+
+var namingConventions = []options.AliasType{options.CamelCase, options.PascalCase, options.SnakeCase, options.UpperSnakeCase, options.KebabCase, options.DotCase}
+
+var defaultDelimiters = []string{`"`, `'`, "`"}
+
+// Init surveys the repository and writes a .launchdarkly/coderefs.yaml file with the naming convention aliases and delimiters that match
+// the project's flag keys in the repository
+func Init(opts options.Options, force bool) error {
+	if opts.ProjKey == "" {
+		return fmt.Errorf("missing required option(s): %v", []string{"projKey"})
+	}
+	absPath, err := validation.NormalizeAndValidatePath(opts.Dir)
+	if err != nil {
+		return fmt.Errorf("could not validate directory option: %w", err)
+	}
+	path := filepath.Join(absPath, ".launchdarkly", "coderefs.yaml")
+	if validation.FileExists(path) && !force {
+		return fmt.Errorf("%s already exists. Use --force to replace it", path)
+	}
+
+	opts.Projects = []options.Project{{Key: opts.ProjKey}}
+	flagKeys := flags.ListFlagKeys(opts)[opts.ProjKey]
+	candidates := namingConventionCandidates(flagKeys)
+	candidateKeys := make([]string, 0, len(candidates))
+	for candidate := range candidates {
+		candidateKeys = append(candidateKeys, candidate)
+	}
+	sort.Strings(candidateKeys)
+
+	log.Info.Printf("surveying %d flag keys and %d naming convention aliases in directory: %s", len(flagKeys), len(candidateKeys), absPath)
+	survey, err := search.SurveyRepository(absPath, flagKeys, candidateKeys)
+	if err != nil {
+		return err
+	}
+
+	repoName := opts.RepoName
+	if repoName == "" {
+		repoName = filepath.Base(absPath)
+	}
+	config := suggestConfig(opts.ProjKey, repoName, survey, candidates)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil { //nolint:mnd
+		return err
+	}
+	/* #nosec */
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil { //nolint:mnd
+		return err
+	}
+	log.Info.Printf("wrote configuration to %s. Run `ld-find-code-refs validate` after editing it", path)
+	return nil
+}
+
+// namingConventionCandidates returns the naming convention aliases of the flag keys, and the alias types generating each alias.
+// Aliases that are flag keys, or are likely to cause false positives, are excluded.
+func namingConventionCandidates(flagKeys []string) map[string][]options.AliasType {
+	isFlagKey := make(map[string]bool, len(flagKeys))
+	for _, key := range flagKeys {
+		isFlagKey[key] = true
+	}
+	candidates := map[string][]options.AliasType{}
+	for _, key := range flagKeys {
+		for _, t := range namingConventions {
+			alias, err := aliases.GenerateNamingConventionAlias(options.Alias{Type: t}, key)
+			if err != nil || isFlagKey[alias] || aliases.WeakAliasReason(alias) != "" {
+				continue
+			}
+			if !containsAliasType(candidates[alias], t) {
+				candidates[alias] = append(candidates[alias], t)
+			}
+		}
+	}
+	return candidates
+}
+
+func containsAliasType(types []options.AliasType, t options.AliasType) bool {
+	for _, existing := range types {
+		if existing == t {
+			return true
+		}
+	}
+	return false
+}
+
+type suggestion struct {
+	name    string
+	matches int
+	example string
+}
+
+func sortSuggestions(suggestions []suggestion) {
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].matches != suggestions[j].matches {
+			return suggestions[i].matches > suggestions[j].matches
+		}
+		return suggestions[i].name < suggestions[j].name
+	})
+}
+
+// suggestConfig returns the contents of a commented configuration file for the results of a survey
+func suggestConfig(projKey, repoName string, survey search.Survey, candidates map[string][]options.AliasType) string {
+	var b strings.Builder
+	b.WriteString("# Generated by `ld-find-code-refs init`. See https://github.com/launchdarkly/ld-find-code-refs/blob/main/docs/CONFIGURATION.md\n")
+	b.WriteString("# yaml-language-server: $schema=https://raw.githubusercontent.com/launchdarkly/ld-find-code-refs/main/docs/coderefs.schema.json\n\n")
+
+	if len(survey.Files) > 0 {
+		fmt.Fprintf(&b, "# Languages: %s\n", formatCounts(survey.Files, "file"))
+	}
+	if len(survey.EvaluationFiles) > 0 {
+		fmt.Fprintf(&b, "# LaunchDarkly and OpenFeature SDK evaluation calls: %s\n", formatCounts(survey.EvaluationFiles, "file"))
+	} else {
+		b.WriteString("# No LaunchDarkly or OpenFeature SDK evaluation calls were found\n")
+	}
+	b.WriteString("\n")
+
+	fmt.Fprintf(&b, "projKey: %s\n", strconv.Quote(projKey))
+	fmt.Fprintf(&b, "repoName: %s\n\n", strconv.Quote(repoName))
+
+	writeAliases(&b, survey, candidates)
+	writeDelimiters(&b, survey)
+	writeProjects(&b, projKey, survey.ProjectDirs)
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+func writeAliases(b *strings.Builder, survey search.Survey, candidates map[string][]options.AliasType) {
+	byType := map[options.AliasType]*suggestion{}
+	for alias, types := range candidates {
+		matches := survey.Candidates[alias]
+		if matches == 0 {
+			continue
+		}
+		for _, t := range types {
+			s, ok := byType[t]
+			if !ok {
+				s = &suggestion{name: string(t)}
+				byType[t] = s
+			}
+			s.matches += matches
+			if s.example == "" || alias < s.example {
+				s.example = alias
+			}
+		}
+	}
+	suggestions := []suggestion{}
+	for _, s := range byType {
+		if s.matches >= minSuggestionMatches {
+			suggestions = append(suggestions, *s)
+		}
+	}
+	sortSuggestions(suggestions)
+
+	if len(suggestions) == 0 {
+		b.WriteString("# No naming convention aliases of flag keys were found. See docs/ALIASES.md to find references to flags stored in variables.\n")
+		b.WriteString("# aliases:\n#   - type: camelcase\n\n")
+		return
+	}
+	b.WriteString("# Naming conventions of flag keys found in the repository. See docs/ALIASES.md for other types of aliases.\n")
+	b.WriteString("aliases:\n")
+	for _, s := range suggestions {
+		fmt.Fprintf(b, "  - type: %s # %d matches, e.g. %s\n", s.name, s.matches, s.example)
+	}
+	b.WriteString("\n")
+}
+
+func writeDelimiters(b *strings.Builder, survey search.Survey) {
+	additional, pairs := []suggestion{}, []suggestion{}
+	for pair, matches := range survey.Delimiters {
+		if matches < minSuggestionMatches || pair.Left == pair.Right && isDefaultDelimiter(pair.Left) {
+			continue
+		}
+		if pair.Left == pair.Right {
+			additional = append(additional, suggestion{name: pair.Left, matches: matches})
+		} else {
+			pairs = append(pairs, suggestion{name: pair.Left + pair.Right, matches: matches})
+		}
+	}
+	if len(additional) == 0 && len(pairs) == 0 {
+		return
+	}
+	sortSuggestions(additional)
+	sortSuggestions(pairs)
+
+	b.WriteString("# Characters found around flag keys, in addition to quotes. Remove any that are not part of references.\n")
+	b.WriteString("delimiters:\n")
+	if len(additional) > 0 {
+		b.WriteString("  additional:\n")
+		for _, s := range additional {
+			fmt.Fprintf(b, "    - %s # %d matches\n", strconv.Quote(s.name), s.matches)
+		}
+	}
+	if len(pairs) > 0 {
+		b.WriteString("  pairs:\n")
+		for _, s := range pairs {
+			fmt.Fprintf(b, "    - left: %s # %d matches\n      right: %s\n", strconv.Quote(s.name[:1]), s.matches, strconv.Quote(s.name[1:]))
+		}
+	}
+	b.WriteString("\n")
+}
+
+func isDefaultDelimiter(delimiter string) bool {
+	for _, d := range defaultDelimiters {
+		if d == delimiter {
+			return true
+		}
+	}
+	return false
+}
+
+func writeProjects(b *strings.Builder, projKey string, dirs []string) {
+	// a single package does not need a project for each directory
+	if len(dirs) < 2 { //nolint:mnd
+		return
+	}
+	b.WriteString("# Directories of packages or services found in the repository. To search for a different LaunchDarkly\n")
+	b.WriteString("# project in each directory, replace projKey with projects and set the key of each project.\n")
+	b.WriteString("# projects:\n")
+	for _, dir := range dirs {
+		fmt.Fprintf(b, "#   - key: %s\n#     dir: %s\n", projKey, dir)
+	}
+	b.WriteString("\n")
+}
+
+// formatCounts formats counts by name, most frequent first, e.g. `go (12 files), python (1 file)`
+func formatCounts(counts map[string]int, unit string) string {
+	suggestions := make([]suggestion, 0, len(counts))
+	for name, count := range counts {
+		suggestions = append(suggestions, suggestion{name: name, matches: count})
+	}
+	sortSuggestions(suggestions)
+	formatted := make([]string, 0, len(suggestions))
+	for _, s := range suggestions {
+		plural := unit
+		if s.matches != 1 {
+			plural += "s"
+		}
+		formatted = append(formatted, fmt.Sprintf("%s (%d %s)", s.name, s.matches, plural))
+	}
+	return strings.Join(formatted, ", ")
+}
@@ -0,0 +1,211 @@
+package coderefs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/helpers"
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/ld"
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/log"
+	"github.com/launchdarkly/ld-find-code-refs/v2/options"
+)
+
+type repositoryRow struct {
+	Name              string `json:"name"`
+	Type              string `json:"type"`
+	SourceLink        string `json:"sourceLink"`
+	DefaultBranch     string `json:"defaultBranch"`
+	Enabled           bool   `json:"enabled"`
+	CommitUrlTemplate string `json:"commitUrlTemplate,omitempty"`
+	HunkUrlTemplate   string `json:"hunkUrlTemplate,omitempty"`
+}
+
+func newRepositoryRow(repo ld.RepoRep) repositoryRow {
+	return repositoryRow{
+		Name:              repo.Name,
+		Type:              repo.Type,
+		SourceLink:        repo.Url,
+		DefaultBranch:     repo.DefaultBranch,
+		Enabled:           repo.Enabled,
+		CommitUrlTemplate: repo.CommitUrlTemplate,
+		HunkUrlTemplate:   repo.HunkUrlTemplate,
+	}
+}
+
+func (r repositoryRow) toRecord() []string {
+	return []string{r.Name, r.Type, r.SourceLink, r.DefaultBranch, strconv.FormatBool(r.Enabled)}
+}
+
+var repositoryHeader = []string{"name", "type", "sourceLink", "defaultBranch", "enabled"}
+
+type branchRow struct {
+	Name       string `json:"name"`
+	Head       string `json:"head"`
+	SyncTime   string `json:"syncTime"`
+	CommitTime string `json:"commitTime,omitempty"`
+	Files      int    `json:"files"`
+	References int    `json:"references"`
+}
+
+func newBranchRow(branch ld.BranchRep) branchRow {
+	return branchRow{
+		Name:       branch.Name,
+		Head:       branch.Head,
+		SyncTime:   formatMillis(branch.SyncTime),
+		CommitTime: formatMillis(branch.CommitTime),
+		Files:      len(branch.References),
+		References: branch.TotalHunkCount(),
+	}
+}
+
+func (r branchRow) toRecord() []string {
+	return []string{r.Name, r.Head, r.SyncTime, r.CommitTime, strconv.Itoa(r.Files), strconv.Itoa(r.References)}
+}
+
+var branchHeader = []string{"name", "head", "syncTime", "commitTime", "files", "references"}
+
+func formatMillis(millis int64) string {
+	if millis == 0 {
+		return ""
+	}
+	return time.UnixMilli(millis).UTC().Format(time.RFC3339)
+}
+
+func newRepositoryApiClient(opts options.Options) (ld.ApiClient, error) {
+	if opts.AccessToken == "" {
+		return ld.ApiClient{}, fmt.Errorf("missing required option(s): %v", []string{"accessToken"})
+	}
+	return ld.InitApiClient(ld.ApiOptions{ApiKey: opts.AccessToken, BaseUri: opts.BaseUri, UserAgent: helpers.GetUserAgent(opts.UserAgent)}), nil
+}
+
+// ListRepositories writes every code reference repository stored in LaunchDarkly to w
+func ListRepositories(opts options.Options, format string, w io.Writer) error {
+	ldApi, err := newRepositoryApiClient(opts)
+	if err != nil {
+		return err
+	}
+	repos, err := ldApi.GetCodeReferenceRepositories()
+	if err != nil {
+		return err
+	}
+	rows := make([]repositoryRow, 0, len(repos))
+	for _, repo := range repos {
+		rows = append(rows, newRepositoryRow(repo))
+	}
+	return writeRepositories(w, format, rows)
+}
+
+// GetRepository writes the settings of a code reference repository stored in LaunchDarkly to w
+func GetRepository(opts options.Options, name, format string, w io.Writer) error {
+	ldApi, err := newRepositoryApiClient(opts)
+	if err != nil {
+		return err
+	}
+	repo, err := ldApi.GetCodeReferenceRepository(name)
+	if err != nil {
+		return fmt.Errorf("could not get repository %s: %w", name, err)
+	}
+	row := newRepositoryRow(*repo)
+	if strings.ToLower(format) == "json" {
+		return writeJSON(w, row)
+	}
+	return writeRepositories(w, format, []repositoryRow{row})
+}
+
+// SetRepositoryEnabled enables or disables a code reference repository stored in LaunchDarkly
+func SetRepositoryEnabled(opts options.Options, name string, enabled bool) error {
+	ldApi, err := newRepositoryApiClient(opts)
+	if err != nil {
+		return err
+	}
+	if err := ldApi.SetCodeReferenceRepositoryEnabled(name, enabled); err != nil {
+		return fmt.Errorf("could not update repository %s: %w", name, err)
+	}
+	if enabled {
+		log.Info.Printf("enabled repository: %s", name)
+	} else {
+		log.Info.Printf("disabled repository: %s", name)
+	}
+	return nil
+}
+
+// DeleteRepository deletes a code reference repository, and the code references of all of its branches, from LaunchDarkly
+func DeleteRepository(opts options.Options, name string) error {
+	ldApi, err := newRepositoryApiClient(opts)
+	if err != nil {
+		return err
+	}
+	if err := ldApi.DeleteCodeReferenceRepository(name); err != nil {
+		return fmt.Errorf("could not delete repository %s: %w", name, err)
+	}
+	log.Info.Printf("deleted repository: %s", name)
+	return nil
+}
+
+// ListBranches writes the branches of a code reference repository stored in LaunchDarkly to w, with their reference counts
+func ListBranches(opts options.Options, repoName, format string, w io.Writer) error {
+	ldApi, err := newRepositoryApiClient(opts)
+	if err != nil {
+		return err
+	}
+	branches, err := ldApi.GetCodeReferenceRepositoryBranches(repoName)
+	if err != nil {
+		return fmt.Errorf("could not get branches of repository %s: %w", repoName, err)
+	}
+	rows := make([]branchRow, 0, len(branches))
+	for _, branch := range branches {
+		rows = append(rows, newBranchRow(branch))
+	}
+	return writeBranches(w, format, rows)
+}
+
+func writeRepositories(w io.Writer, format string, rows []repositoryRow) error {
+	switch strings.ToLower(format) {
+	case "json":
+		return writeJSON(w, rows)
+	case "", "table":
+		records := make([][]string, 0, len(rows))
+		for _, r := range rows {
+			records = append(records, r.toRecord())
+		}
+		return writeTable(w, repositoryHeader, records)
+	default:
+		return fmt.Errorf(`invalid value %q for "format": must be table or json`, format)
+	}
+}
+
+func writeBranches(w io.Writer, format string, rows []branchRow) error {
+	switch strings.ToLower(format) {
+	case "json":
+		return writeJSON(w, rows)
+	case "", "table":
+		records := make([][]string, 0, len(rows))
+		for _, r := range rows {
+			records = append(records, r.toRecord())
+		}
+		return writeTable(w, branchHeader, records)
+	default:
+		return fmt.Errorf(`invalid value %q for "format": must be table or json`, format)
+	}
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeTable(w io.Writer, header []string, records [][]string) error {
+	table := tablewriter.NewWriter(w)
+	table.Header(header)
+	if err := table.Bulk(records); err != nil {
+		return err
+	}
+	return table.Render()
+}
@@ -0,0 +1,78 @@
+package flags
+
+import (
+	"regexp"
+
+	ldapi "github.com/launchdarkly/api-client-go/v17"
+
+	"github.com/launchdarkly/ld-find-code-refs/v2/options"
+)
+
+type flagFilter struct {
+	options.FlagFilter
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+func newFlagFilter(filter options.FlagFilter) (flagFilter, error) {
+	f := flagFilter{FlagFilter: filter}
+	for _, pattern := range filter.Include {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return f, err
+		}
+		f.include = append(f.include, re)
+	}
+	for _, pattern := range filter.Exclude {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return f, err
+		}
+		f.exclude = append(f.exclude, re)
+	}
+	return f, nil
+}
+
+// matches returns true if a flag matches every condition of the filter
+func (f flagFilter) matches(flag ldapi.FeatureFlag) bool {
+	switch f.Type.Canonical() {
+	case options.TemporaryFlags:
+		if !flag.Temporary {
+			return false
+		}
+	case options.PermanentFlags:
+		if flag.Temporary {
+			return false
+		}
+	}
+	if len(f.Tags) > 0 && !containsAny(flag.Tags, f.Tags) {
+		return false
+	}
+	if len(f.MaintainerTeams) > 0 && (flag.MaintainerTeamKey == nil || !containsAny([]string{*flag.MaintainerTeamKey}, f.MaintainerTeams)) {
+		return false
+	}
+	if len(f.include) > 0 && !matchesAny(f.include, flag.Key) {
+		return false
+	}
+	return !matchesAny(f.exclude, flag.Key)
+}
+
+func containsAny(values, wanted []string) bool {
+	for _, v := range values {
+		for _, w := range wanted {
+			if v == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesAny(patterns []*regexp.Regexp, key string) bool {
+	for _, re := range patterns {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
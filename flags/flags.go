@@ -2,6 +2,7 @@ package flags
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/launchdarkly/ld-find-code-refs/v2/internal/helpers"
 	"github.com/launchdarkly/ld-find-code-refs/v2/internal/ld"
@@ -9,11 +10,16 @@ import (
 	"github.com/launchdarkly/ld-find-code-refs/v2/options"
 )
 
-const (
-	minFlagKeyLen = 3 // Minimum flag key length helps reduce the number of false positives
-)
+// FlagKeys are the flag keys of each configured project
+type FlagKeys struct {
+	// Searched are the keys of the flags to search for in code
+	Searched map[string][]string
+	// All are the keys of every flag in the project, including flags omitted by the flag filter or the minimum flag key length.
+	// They tell keys that are not searched for apart from keys that are not flags.
+	All map[string][]string
+}
 
-func GetFlagKeys(opts options.Options, repoParams ld.RepoParams) map[string][]string {
+func GetFlagKeys(opts options.Options, repoParams ld.RepoParams) FlagKeys {
 	isDryRun := opts.DryRun
 	ldApi := ld.InitApiClient(ld.ApiOptions{ApiKey: opts.AccessToken, BaseUri: opts.BaseUri, UserAgent: helpers.GetUserAgent(opts.UserAgent)})
 	ignoreServiceErrors := opts.IgnoreServiceErrors
@@ -25,24 +31,39 @@ func GetFlagKeys(opts options.Options, repoParams ld.RepoParams) map[string][]st
 		}
 	}
 
-	flagKeys := make(map[string][]string)
+	return getFlagKeys(ldApi, opts)
+}
+
+// ListFlagKeys returns the flag keys for each configured project without creating or updating the code reference repository
+func ListFlagKeys(opts options.Options) map[string][]string {
+	ldApi := ld.InitApiClient(ld.ApiOptions{ApiKey: opts.AccessToken, BaseUri: opts.BaseUri, UserAgent: helpers.GetUserAgent(opts.UserAgent)})
+	return getFlagKeys(ldApi, opts).Searched
+}
+
+func getFlagKeys(ldApi ld.ApiClient, opts options.Options) FlagKeys {
+	flagKeys := FlagKeys{Searched: make(map[string][]string), All: make(map[string][]string)}
 	for _, proj := range opts.Projects {
-		flags, err := getFlags(ldApi, proj.Key, opts.SkipArchivedFlags)
+		flags, allFlags, err := getFlags(ldApi, proj.Key, opts.ProjectSkipArchivedFlags(proj), opts.ProjectFlagFilter(proj))
 		if err != nil {
-			helpers.FatalServiceError(fmt.Errorf("could not retrieve flag keys from LaunchDarkly for project `%s`: %w", proj.Key, err), ignoreServiceErrors)
+			helpers.FatalServiceError(fmt.Errorf("could not retrieve flag keys from LaunchDarkly for project `%s`: %w", proj.Key, err), opts.IgnoreServiceErrors)
 		}
-		addFlagKeys(flagKeys, flags, proj.Key)
+		flagKeys.All[proj.Key] = allFlags
+		addFlagKeys(flagKeys.Searched, flags, proj.Key, opts.ProjectMinFlagKeyLength(proj), opts.ProjectShortFlagKeys(proj))
 	}
 	return flagKeys
 }
 
 // Very short flag keys lead to many false positives when searching in code,
-// so we filter them out.
-func filterShortFlagKeys(flags []string) (filtered []string, omitted []string) {
+// so we filter them out unless they are in the list of allowed short flag keys.
+func filterShortFlagKeys(flags []string, minLength int, shortFlagKeys []string) (filtered []string, omitted []string) {
+	allowed := make(map[string]bool, len(shortFlagKeys))
+	for _, key := range shortFlagKeys {
+		allowed[key] = true
+	}
 	filteredFlags := []string{}
 	omittedFlags := []string{}
 	for _, flag := range flags {
-		if len(flag) >= minFlagKeyLen {
+		if len(flag) >= minLength || allowed[flag] {
 			filteredFlags = append(filteredFlags, flag)
 		} else {
 			omittedFlags = append(omittedFlags, flag)
@@ -51,22 +72,42 @@ func filterShortFlagKeys(flags []string) (filtered []string, omitted []string) {
 	return filteredFlags, omittedFlags
 }
 
-func addFlagKeys(flagKeys map[string][]string, flags []string, projKey string) {
-	filteredFlags, omittedFlags := filterShortFlagKeys(flags)
+func addFlagKeys(flagKeys map[string][]string, flags []string, projKey string, minLength int, shortFlagKeys []string) {
+	filteredFlags, omittedFlags := filterShortFlagKeys(flags, minLength, shortFlagKeys)
+	if len(omittedFlags) > 0 {
+		log.Debug.Printf("omitted flags with keys shorter than %d for project %s: %s", minLength, projKey, strings.Join(omittedFlags, ", "))
+	}
 	if len(filteredFlags) == 0 {
 		log.Warning.Printf("no flag keys longer than the minimum flag key length (%v) were found for project: %s. Skipping project",
-			minFlagKeyLen, projKey)
+			minLength, projKey)
 		return
 	} else if len(omittedFlags) > 0 {
-		log.Warning.Printf("omitting %d flags with keys less than minimum (%d) for project: %s", len(omittedFlags), minFlagKeyLen, projKey)
+		log.Warning.Printf("omitting %d flags with keys less than minimum (%d) for project: %s", len(omittedFlags), minLength, projKey)
 	}
 	flagKeys[projKey] = filteredFlags
 }
 
-func getFlags(ldApi ld.ApiClient, projKey string, skipArchivedFlags bool) ([]string, error) {
-	flags, err := ldApi.GetFlagKeyList(projKey, skipArchivedFlags)
+// getFlags returns the keys of flags matching the flag filter, and the keys of all flags in the project. Every flag is requested,
+// so the filter is only applied locally.
+func getFlags(ldApi ld.ApiClient, projKey string, skipArchivedFlags bool, filter options.FlagFilter) (flagKeys, allFlagKeys []string, err error) {
+	f, err := newFlagFilter(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	flags, err := ldApi.GetFlagList(projKey, skipArchivedFlags, "")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	flagKeys = make([]string, 0, len(flags))
+	allFlagKeys = make([]string, 0, len(flags))
+	for _, flag := range flags {
+		allFlagKeys = append(allFlagKeys, flag.Key)
+		if f.matches(flag) {
+			flagKeys = append(flagKeys, flag.Key)
+		}
+	}
+	if len(flagKeys) < len(flags) {
+		log.Info.Printf("searching for %d of %d flags matching the flag filter for project: %s", len(flagKeys), len(flags), projKey)
 	}
-	return flags, nil
+	return flagKeys, allFlagKeys, nil
 }
@@ -1,12 +1,17 @@
 package flags
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
+	ldapi "github.com/launchdarkly/api-client-go/v17"
 	"github.com/stretchr/testify/require"
 
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/ld"
 	"github.com/launchdarkly/ld-find-code-refs/v2/internal/log"
+	"github.com/launchdarkly/ld-find-code-refs/v2/options"
 )
 
 func init() {
@@ -48,7 +53,7 @@ func Test_filterShortFlags(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, _ := filterShortFlagKeys(tt.flags)
+			got, _ := filterShortFlagKeys(tt.flags, options.DefaultMinFlagKeyLength, nil)
 			require.Equal(t, tt.want, got)
 		})
 	}
@@ -109,8 +114,65 @@ func Test_addFlagKeys(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			addFlagKeys(tt.flagKeys, tt.flags, tt.projKey)
+			addFlagKeys(tt.flagKeys, tt.flags, tt.projKey, options.DefaultMinFlagKeyLength, nil)
 			require.Equal(t, tt.want, tt.flagKeys)
 		})
 	}
 }
+
+func Test_filterShortFlagKeys_configured(t *testing.T) {
+	filtered, omitted := filterShortFlagKeys([]string{"ai", "ui", "beta", "checkout"}, 5, []string{"ai", "beta"})
+	require.Equal(t, []string{"ai", "beta", "checkout"}, filtered)
+	require.Equal(t, []string{"ui"}, omitted)
+
+	filtered, omitted = filterShortFlagKeys([]string{"x", "ui"}, 0, nil)
+	require.Equal(t, []string{"x", "ui"}, filtered)
+	require.Empty(t, omitted)
+}
+
+func Test_flagFilter(t *testing.T) {
+	platform := "platform"
+	flags := []ldapi.FeatureFlag{
+		{Key: "platform-rate-limit", Temporary: true, Tags: []string{"backend"}, MaintainerTeamKey: &platform},
+		{Key: "platform-test-flag", Temporary: true, Tags: []string{"backend"}, MaintainerTeamKey: &platform},
+		{Key: "platform-kill-switch", Temporary: false, Tags: []string{"ops"}},
+		{Key: "checkout-redesign", Temporary: true, Tags: []string{"frontend", "backend"}},
+	}
+	matchingKeys := func(filter options.FlagFilter) []string {
+		f, err := newFlagFilter(filter)
+		require.NoError(t, err)
+		keys := []string{}
+		for _, flag := range flags {
+			if f.matches(flag) {
+				keys = append(keys, flag.Key)
+			}
+		}
+		return keys
+	}
+
+	require.Equal(t, []string{"platform-rate-limit", "platform-test-flag", "platform-kill-switch", "checkout-redesign"}, matchingKeys(options.FlagFilter{}))
+	require.Equal(t, []string{"platform-rate-limit", "platform-kill-switch"}, matchingKeys(options.FlagFilter{Include: []string{"^platform-"}, Exclude: []string{"-test-"}}))
+	require.Equal(t, []string{"platform-kill-switch"}, matchingKeys(options.FlagFilter{Type: "Permanent"}))
+	require.Equal(t, []string{"platform-kill-switch", "checkout-redesign"}, matchingKeys(options.FlagFilter{Tags: []string{"ops", "frontend"}}))
+	require.Equal(t, []string{"platform-rate-limit", "platform-test-flag"}, matchingKeys(options.FlagFilter{MaintainerTeams: []string{"platform"}, Type: options.TemporaryFlags}))
+}
+
+func Test_getFlags(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/api/v2/projects/default/environments" {
+			_, err := res.Write([]byte(`{"items":[{"key":"production"}]}`))
+			require.NoError(t, err)
+			return
+		}
+		_, err := res.Write([]byte(`{"items":[{"key":"checkout-redesign","temporary":true},{"key":"kill-switch","temporary":false}],"totalCount":2}`))
+		require.NoError(t, err)
+	}))
+	defer testServer.Close()
+
+	retryMax := 0
+	ldApi := ld.InitApiClient(ld.ApiOptions{ApiKey: "api-x", BaseUri: testServer.URL, RetryMax: &retryMax})
+	flagKeys, allFlagKeys, err := getFlags(ldApi, "default", true, options.FlagFilter{Type: options.TemporaryFlags})
+	require.NoError(t, err)
+	require.Equal(t, []string{"checkout-redesign"}, flagKeys)
+	require.Equal(t, []string{"checkout-redesign", "kill-switch"}, allFlagKeys)
+}
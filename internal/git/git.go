@@ -195,6 +195,19 @@ func (c *Client) commitTime() (commitTime int64, err error) {
 	return commitTime, nil
 }
 
+// IsShallow returns true if the repository is a shallow clone, so commits before the shallow boundary are missing
+func (c *Client) IsShallow() (bool, error) {
+	repo, err := git.PlainOpen(c.workspace)
+	if err != nil {
+		return false, err
+	}
+	shallow, err := repo.Storer.Shallow()
+	if err != nil {
+		return false, err
+	}
+	return len(shallow) > 0, nil
+}
+
 func (c *Client) RemoteBranches() (branches map[string]bool, err error) {
 	branches = map[string]bool{}
 	repo, err := git.PlainOpen(c.workspace)
@@ -276,17 +289,44 @@ func (c *Client) FindExtinctions(project options.Project, flags []string, matche
 		flagMap := getFlagDeltaMap(flags)
 
 		for _, filePatch := range patch.FilePatches() {
-			if !shouldScanFilePatch(project.Dir, filePatch) {
+			if !shouldScanFilePatch(elementMatcher, filePatch) {
 				continue
 			}
 
+			path := patchPath(filePatch)
+			fromLines, toLines := patchContents(filePatch)
+			fromIgnored, toIgnored := search.IsIgnoredFile(fromLines), search.IsIgnoredFile(toLines)
+			var fromComments, toComments map[int]bool
+			if matcher.SkipsComments() {
+				// references that are commented out are removed, like the scan of the current revision tags or ignores them
+				fromPath, toPath := patchPaths(filePatch)
+				fromComments, toComments = search.CommentLines(fromPath, fromLines), search.CommentLines(toPath, toLines)
+			}
+			// 0-based numbers of the first line of the chunk in the file before and after the change
+			fromLine, toLine := 0, 0
 			for _, chunk := range filePatch.Chunks() {
+				lines := chunkLines(chunk)
 				delta := getDeltaFromChunkType(chunk.Type())
-				if delta == 0 {
+				firstLine, comments := fromLine, fromComments
+				switch chunk.Type() {
+				case diff.Equal:
+					fromLine += len(lines)
+					toLine += len(lines)
+				case diff.Delete:
+					fromLine += len(lines)
+				case diff.Add:
+					firstLine, comments = toLine, toComments
+					toLine += len(lines)
+				}
+				if delta == 0 || delta > 0 && fromIgnored || delta < 0 && toIgnored {
 					continue
 				}
-				for _, line := range strings.Split(chunk.Content(), "\n") {
-					for _, el := range elementMatcher.FindMatches(line) {
+				for i, line := range lines {
+					if search.IsIgnoredLine(line) || comments[firstLine+i+1] {
+						continue
+					}
+					// references through key templates are low-confidence, so they don't keep a flag from being removed
+					for _, el := range elementMatcher.FindExactMatches(path, line) {
 						if _, ok := flagMap[el]; ok {
 							flagMap[el] += delta
 						}
@@ -311,27 +351,74 @@ func (c *Client) FindExtinctions(project options.Project, flags []string, matche
 }
 
 // Determine if changed file should be scanned
-func shouldScanFilePatch(projectDir string, filePatch diff.FilePatch) bool {
+func shouldScanFilePatch(elementMatcher *search.ElementMatcher, filePatch diff.FilePatch) bool {
 	fromFile, toFile := filePatch.Files()
 	printDebugStatement(fromFile, toFile)
 
-	if projectDir == "" {
-		return true
-	}
+	// Ignore files outside of the project directory, and files the project does not search
 
-	// Ignore files outside of the project directory
-
-	if toFile != nil && strings.HasPrefix(toFile.Path(), projectDir) {
+	if toFile != nil && elementMatcher.SearchesPath(toFile.Path()) {
 		return true
 	}
 
-	if fromFile != nil && strings.HasPrefix(fromFile.Path(), projectDir) {
+	if fromFile != nil && elementMatcher.SearchesPath(fromFile.Path()) {
 		return true
 	}
 
 	return false
 }
 
+// patchPaths returns the paths of the file before and after the change, which are empty if the file was added or removed
+func patchPaths(filePatch diff.FilePatch) (from, to string) {
+	fromFile, toFile := filePatch.Files()
+	if fromFile != nil {
+		from = fromFile.Path()
+	}
+	if toFile != nil {
+		to = toFile.Path()
+	}
+	return from, to
+}
+
+// patchPath returns the path of the changed file, or the path of the removed file
+func patchPath(filePatch diff.FilePatch) string {
+	fromFile, toFile := filePatch.Files()
+	if toFile != nil {
+		return toFile.Path()
+	}
+	if fromFile != nil {
+		return fromFile.Path()
+	}
+	return ""
+}
+
+// patchContents rebuilds the lines of the file before and after the change from the chunks of its patch.
+// The chunks of a file patch contain the whole file, so unchanged and removed lines make up the file before the change.
+func patchContents(filePatch diff.FilePatch) (from, to []string) {
+	for _, chunk := range filePatch.Chunks() {
+		lines := chunkLines(chunk)
+		switch chunk.Type() {
+		case diff.Equal:
+			from = append(from, lines...)
+			to = append(to, lines...)
+		case diff.Delete:
+			from = append(from, lines...)
+		case diff.Add:
+			to = append(to, lines...)
+		}
+	}
+	return from, to
+}
+
+// chunkLines returns the lines of a chunk, without the empty line following its last newline
+func chunkLines(chunk diff.Chunk) []string {
+	content := chunk.Content()
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
 func printDebugStatement(fromFile, toFile diff.File) {
 	fromPath, toPath := "FROM_PATH", "TO_PATH"
 	if fromFile != nil {
@@ -8,10 +8,12 @@ import (
 	"time"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/launchdarkly/ld-find-code-refs/v2/flags"
 	"github.com/launchdarkly/ld-find-code-refs/v2/internal/ld"
 	"github.com/launchdarkly/ld-find-code-refs/v2/internal/log"
 	"github.com/launchdarkly/ld-find-code-refs/v2/options"
@@ -106,8 +108,8 @@ func TestFindExtinctions(t *testing.T) {
 	missingFlags := [][]string{{flag1, flag2}, {flag3, flag4}}
 	matcher := search.Matcher{
 		Elements: []search.ElementMatcher{
-			search.NewElementMatcher(projKey.Key, ``, ``, []string{flag1, flag2}, nil),
-			search.NewElementMatcher(addProjKey.Key, ``, ``, []string{flag3, flag4}, nil),
+			search.NewElementMatcher(projKey.Key, ``, nil, []string{flag1, flag2}, nil),
+			search.NewElementMatcher(addProjKey.Key, ``, nil, []string{flag3, flag4}, nil),
 		},
 	}
 
@@ -197,3 +199,128 @@ func incrementCommitTime(who object.Signature) (object.Signature, time.Time) {
 
 	return who, t
 }
+
+func TestIsShallow(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	client := Client{workspace: dir}
+
+	shallow, err := client.IsShallow()
+	require.NoError(t, err)
+	assert.False(t, shallow)
+
+	require.NoError(t, repo.Storer.SetShallow([]plumbing.Hash{plumbing.NewHash("4b825dc642cb6eb9a060e54bf8d69288fbee4904")}))
+	shallow, err = client.IsShallow()
+	require.NoError(t, err)
+	assert.True(t, shallow)
+}
+
+func TestFindExtinctions_ignoreAnnotations(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	who := object.Signature{Name: "LaunchDarkly", Email: "dev@launchdarkly.com", When: time.Unix(100000000, 0)}
+
+	files := map[string]string{
+		"ignored-line.txt": flag1 + " // ld-coderefs-ignore\n",
+		"ignored-file.txt": "// ld-coderefs-ignore-file\n" + flag2 + "\n",
+		"flag3.txt":        flag3 + "\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+		_, err = wt.Add(name)
+		require.NoError(t, err)
+	}
+	_, err = wt.Commit("add flags", &git.CommitOptions{All: true, Committer: &who, Author: &who})
+	require.NoError(t, err)
+
+	for name := range files {
+		require.NoError(t, os.Remove(filepath.Join(dir, name)))
+	}
+	who, _ = incrementCommitTime(who)
+	removal, err := wt.Commit("remove flags", &git.CommitOptions{All: true, Committer: &who, Author: &who})
+	require.NoError(t, err)
+
+	c := Client{workspace: dir}
+	project := options.Project{Key: "default"}
+	matcher := search.Matcher{
+		Elements: []search.ElementMatcher{search.NewElementMatcher(project.Key, ``, nil, []string{flag1, flag2, flag3}, nil)},
+	}
+	extinctions, err := c.FindExtinctions(project, []string{flag1, flag2, flag3}, matcher, 10)
+	require.NoError(t, err)
+	require.Len(t, extinctions, 1)
+	assert.Equal(t, flag3, extinctions[0].FlagKey)
+	assert.Equal(t, removal.String(), extinctions[0].Revision)
+}
+
+func TestFindExtinctions_commentedOut(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	who := object.Signature{Name: "LaunchDarkly", Email: "dev@launchdarkly.com", When: time.Unix(100000000, 0)}
+
+	commit := func(message, content string) plumbing.Hash {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "flags.go"), []byte(content), 0600))
+		_, err := wt.Add("flags.go")
+		require.NoError(t, err)
+		who, _ = incrementCommitTime(who)
+		hash, err := wt.Commit(message, &git.CommitOptions{All: true, Committer: &who, Author: &who})
+		require.NoError(t, err)
+		return hash
+	}
+	commit("add flags", "package flags\n\nvar a = client.BoolVariation(\"flag1\", ctx, false)\nvar b = client.BoolVariation(\"flag2\", ctx, false)\n")
+	removal := commit("comment out flag1", "package flags\n\n// var a = client.BoolVariation(\"flag1\", ctx, false)\nvar b = client.BoolVariation(\"flag2\", ctx, false)\n")
+
+	c := Client{workspace: dir}
+	project := options.Project{Key: "default"}
+	flagKeys := flags.FlagKeys{Searched: map[string][]string{project.Key: {flag1, flag2}}}
+	for _, policy := range []options.CommentPolicy{options.TagComments, options.IgnoreComments} {
+		opts := options.Options{Comments: string(policy), Projects: []options.Project{project}}
+		matcher := search.NewMultiProjectMatcher(opts, dir, flagKeys)
+		extinctions, err := c.FindExtinctions(project, []string{flag1}, matcher, 10)
+		require.NoError(t, err)
+		require.Len(t, extinctions, 1, policy)
+		assert.Equal(t, flag1, extinctions[0].FlagKey)
+		assert.Equal(t, removal.String(), extinctions[0].Revision)
+	}
+
+	opts := options.Options{Comments: string(options.IncludeComments), Projects: []options.Project{project}}
+	extinctions, err := c.FindExtinctions(project, []string{flag1}, search.NewMultiProjectMatcher(opts, dir, flagKeys), 10)
+	require.NoError(t, err)
+	assert.Empty(t, extinctions)
+}
+
+func TestFindExtinctions_keyTemplates(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	who := object.Signature{Name: "LaunchDarkly", Email: "dev@launchdarkly.com", When: time.Unix(100000000, 0)}
+
+	commit := func(message, content string) plumbing.Hash {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "checkout.js"), []byte(content), 0600))
+		_, err := wt.Add("checkout.js")
+		require.NoError(t, err)
+		who, _ = incrementCommitTime(who)
+		hash, err := wt.Commit(message, &git.CommitOptions{All: true, Committer: &who, Author: &who})
+		require.NoError(t, err)
+		return hash
+	}
+	commit("add flag", "variation(\"checkout-a\")\n")
+	removal := commit("build flag key", "variation(\"checkout-\" + variant)\n")
+
+	c := Client{workspace: dir}
+	project := options.Project{Key: "default"}
+	opts := options.Options{KeyTemplates: []string{"checkout-*"}, Projects: []options.Project{project}}
+	matcher := search.NewMultiProjectMatcher(opts, dir, flags.FlagKeys{Searched: map[string][]string{project.Key: {"checkout-a"}}})
+	extinctions, err := c.FindExtinctions(project, []string{"checkout-a"}, matcher, 10)
+	require.NoError(t, err)
+	require.Len(t, extinctions, 1)
+	assert.Equal(t, removal.String(), extinctions[0].Revision)
+}
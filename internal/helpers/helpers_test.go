@@ -0,0 +1,14 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, EditDistance("flag", "flag"))
+	assert.Equal(t, 1, EditDistance("new-chekout", "new-checkout"))
+	assert.Equal(t, 2, EditDistance("dark-mdoe", "dark-mode"))
+	assert.Equal(t, 3, EditDistance("", "abc"))
+}
@@ -0,0 +1,54 @@
+// Package ignore matches paths against the ignore files in the root of a directory
+package ignore
+
+import (
+	"path/filepath"
+
+	"github.com/monochromegane/go-gitignore"
+)
+
+// Files are the ignore files read from the root of a directory
+var Files = []string{".gitignore", ".ignore", ".ldignore"}
+
+type Ignore struct {
+	path    string
+	ignores []gitignore.IgnoreMatcher
+}
+
+// New reads the ignore files in the directory at path. Ignore files that do not exist are skipped.
+func New(path string, ignoreFiles []string) Ignore {
+	ignores := make([]gitignore.IgnoreMatcher, 0, len(ignoreFiles))
+	for _, ignoreFile := range ignoreFiles {
+		i, err := gitignore.NewGitIgnore(filepath.Join(path, ignoreFile))
+		if err != nil {
+			continue
+		}
+		ignores = append(ignores, i)
+	}
+	return Ignore{path: path, ignores: ignores}
+}
+
+func (m Ignore) Match(path string, isDir bool) bool {
+	for _, i := range m.ignores {
+		if i.Match(path, isDir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchPath returns true if the file at path, or one of the directories containing it below the ignore root, is ignored.
+// Use it for paths that are not found by walking the directory, where ignored directories are skipped.
+func (m Ignore) MatchPath(path string) bool {
+	if m.Match(path, false) {
+		return true
+	}
+	root := filepath.Clean(m.path)
+	for dir := filepath.Dir(path); dir != root && dir != "." && dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if m.Match(dir, true) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package lang
+
+// Assignment is a string literal assigned to an identifier, e.g. `const NEW_CHECKOUT = "new-checkout"`
+type Assignment struct {
+	Name  string
+	Value string
+	Line  int
+}
+
+// tokens which may follow a string literal that is assigned without being part of a larger expression
+var assignmentTerminators = map[string]bool{";": true, ",": true, ")": true, "}": true, "]": true}
+
+// FindStringAssignments returns the string literals assigned to identifiers in declarations and assignment statements.
+// Assignments in function call arguments, such as keyword arguments, and to members of other objects are ignored.
+func FindStringAssignments(l *Language, src string) []Assignment {
+	tokens := withoutComments(Tokenize(l, src))
+	ret := []Assignment{}
+
+	// brackets enclosing the current token, and whether each opens a declaration group, e.g. `const ( ... )` in Go
+	type bracket struct {
+		text        string
+		declaration bool
+	}
+	brackets := []bracket{}
+	inExpression := func() bool {
+		for _, b := range brackets {
+			if b.text != "{" && !b.declaration {
+				return true
+			}
+		}
+		return false
+	}
+
+	for i, t := range tokens {
+		if t.Kind != Punct {
+			continue
+		}
+		switch t.Text {
+		case "(", "[", "{":
+			declaration := i > 0 && tokens[i-1].Kind == Ident && l.isKeyword(tokens[i-1].Text)
+			brackets = append(brackets, bracket{text: t.Text, declaration: declaration})
+			continue
+		case ")", "]", "}":
+			if len(brackets) > 0 {
+				brackets = brackets[:len(brackets)-1]
+			}
+			continue
+		case "=", ":=":
+		default:
+			continue
+		}
+
+		if inExpression() || i+1 >= len(tokens) || tokens[i+1].Kind != String {
+			continue
+		}
+		if next := i + 2; next < len(tokens) && tokens[next].Kind != Ident && !assignmentTerminators[tokens[next].Text] {
+			continue
+		}
+		if name, ok := l.assignedName(tokens[:i]); ok {
+			ret = append(ret, Assignment{Name: name, Value: tokens[i+1].Value, Line: tokens[i+1].Line})
+		}
+	}
+	return ret
+}
+
+// assignedName returns the identifier at the end of the tokens preceding an assignment operator, skipping type declarations
+func (l *Language) assignedName(tokens []Token) (string, bool) {
+	at := func(i int) Token {
+		if i < 0 {
+			return Token{Kind: Punct}
+		}
+		return tokens[i]
+	}
+	isName := func(t Token) bool {
+		return t.Kind == Ident && !l.isKeyword(t.Text)
+	}
+
+	j := len(tokens) - 1
+	if !isName(at(j)) {
+		return "", false
+	}
+	// declarations are on a single line, the preceding line may end with an identifier
+	sameLine := func(i int) bool {
+		return i >= 0 && tokens[i].Line == tokens[j].Line
+	}
+	switch {
+	case l.TypeAfterName && isName(at(j-1)) && sameLine(j-1):
+		// const Name Type = "value"
+		return at(j - 1).Text, true
+	case l.TypeAfterName && at(j-1).Text == "." && isName(at(j-3)) && sameLine(j-3):
+		// const Name pkg.Type = "value"
+		return at(j - 3).Text, true
+	case l.TypeAnnotations && at(j-1).Text == ":" && isName(at(j-2)) && sameLine(j-2):
+		// val name: Type = "value"
+		return at(j - 2).Text, true
+	case at(j-1).Text == ".":
+		// assignment to a member of another object
+		return "", false
+	}
+	return at(j).Text, true
+}
+
+func withoutComments(tokens []Token) []Token {
+	ret := make([]Token, 0, len(tokens))
+	for _, t := range tokens {
+		if t.Kind != Comment {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,22 @@
+package lang
+
+import "strings"
+
+// CommentLines returns the 1-based numbers of the lines that contain comments and no code
+func CommentLines(l *Language, src string) map[int]bool {
+	commentLines := map[int]bool{}
+	codeLines := map[int]bool{}
+	for _, t := range Tokenize(l, src) {
+		lines := codeLines
+		if t.Kind == Comment {
+			lines = commentLines
+		}
+		for i := 0; i <= strings.Count(t.Text, "\n"); i++ {
+			lines[t.Line+i] = true
+		}
+	}
+	for line := range codeLines {
+		delete(commentLines, line)
+	}
+	return commentLines
+}
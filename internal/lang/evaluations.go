@@ -0,0 +1,71 @@
+package lang
+
+import (
+	"regexp"
+
+	"github.com/launchdarkly/ld-find-code-refs/v2/options"
+)
+
+// maxKeyArgument is the last argument of an evaluation method that may contain the flag key. LaunchDarkly SDKs take the key as
+// the first argument, while some OpenFeature SDKs take a context first.
+const maxKeyArgument = 2
+
+var validFlagKey = regexp.MustCompile("^[" + options.FlagKeyChars + "]+$")
+
+// EvaluatedKeys returns the flag keys passed as string literals to SDK methods that evaluate flags in a line of source code.
+// Keys built at runtime, such as template strings with interpolation, are ignored.
+func (l *Language) EvaluatedKeys(line string) []string {
+	keys := []string{}
+	if l.evaluationCall == nil {
+		return keys
+	}
+	for _, loc := range l.evaluationCall.FindAllStringIndex(line, -1) {
+		if key, ok := evaluatedKey(Tokenize(l, line[loc[1]:])); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// evaluatedKey returns the first of the leading arguments of a call that is a single string literal. tokens start after the opening parenthesis.
+func evaluatedKey(tokens []Token) (string, bool) {
+	argument := []Token{}
+	argumentIndex, depth := 0, 0
+	for _, t := range tokens {
+		if t.Kind == Comment {
+			continue
+		}
+		if t.Kind == Punct && depth == 0 && (t.Text == "," || t.Text == ")") {
+			if key, ok := stringArgument(argument); ok {
+				return key, true
+			}
+			argumentIndex++
+			if t.Text == ")" || argumentIndex >= maxKeyArgument {
+				return "", false
+			}
+			argument = argument[:0]
+			continue
+		}
+		if t.Kind == Punct {
+			switch t.Text {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				depth--
+			}
+		}
+		argument = append(argument, t)
+	}
+	return "", false
+}
+
+// stringArgument returns the value of an argument consisting of a string literal, optionally labeled, e.g. `forKey: "key"` or `key="key"`
+func stringArgument(argument []Token) (string, bool) {
+	if len(argument) == 3 && argument[0].Kind == Ident && argument[1].Kind == Punct && (argument[1].Text == ":" || argument[1].Text == "=") {
+		argument = argument[2:]
+	}
+	if len(argument) != 1 || argument[0].Kind != String || !validFlagKey.MatchString(argument[0].Value) {
+		return "", false
+	}
+	return argument[0].Value, true
+}
@@ -0,0 +1,211 @@
+package lang
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type BlockComment struct {
+	Start string
+	End   string
+}
+
+// Language describes the lexical syntax of a programming language
+type Language struct {
+	Name          string
+	Extensions    []string
+	LineComments  []string
+	BlockComments []BlockComment
+	// String delimiters, longest first so triple quotes are matched before single quotes
+	Quotes []string
+	// String delimiters that do not support escape sequences
+	RawQuotes []string
+	// Whether declarations name the identifier before its type without a separator, e.g. `const Name string = "value"` in Go
+	TypeAfterName bool
+	// Whether declarations may annotate an identifier with a type following a colon, e.g. `val name: String = "value"`
+	TypeAnnotations bool
+	// Keywords which may precede the name of a declaration
+	Keywords []string
+	// Names of LaunchDarkly and OpenFeature SDK methods and hooks that evaluate flags
+	EvaluationMethods []string
+
+	evaluationCall *regexp.Regexp
+}
+
+var cStyleComments = []BlockComment{{Start: "/*", End: "*/"}}
+
+var languages = []Language{
+	{
+		Name:          "go",
+		Extensions:    []string{".go"},
+		LineComments:  []string{"//"},
+		BlockComments: cStyleComments,
+		Quotes:        []string{`"`, `'`, "`"},
+		RawQuotes:     []string{"`"},
+		TypeAfterName: true,
+		Keywords:      []string{"const", "var"},
+		EvaluationMethods: []string{
+			"BoolVariation", "StringVariation", "IntVariation", "Float64Variation", "JSONVariation",
+			"BoolVariationDetail", "StringVariationDetail", "IntVariationDetail", "Float64VariationDetail", "JSONVariationDetail",
+			"BoolVariationCtx", "StringVariationCtx", "IntVariationCtx", "Float64VariationCtx", "JSONVariationCtx",
+			"BooleanValue", "StringValue", "IntValue", "FloatValue", "ObjectValue",
+			"BooleanValueDetails", "StringValueDetails", "IntValueDetails", "FloatValueDetails", "ObjectValueDetails",
+		},
+	},
+	{
+		Name:            "javascript",
+		Extensions:      []string{".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".mts", ".cts"},
+		LineComments:    []string{"//"},
+		BlockComments:   cStyleComments,
+		Quotes:          []string{`"`, `'`, "`"},
+		TypeAnnotations: true,
+		Keywords:        []string{"const", "let", "var", "export", "static", "readonly", "public", "private", "protected"},
+		EvaluationMethods: []string{
+			"variation", "variationDetail", "boolVariation", "boolVariationDetail", "stringVariation", "stringVariationDetail",
+			"numberVariation", "numberVariationDetail", "jsonVariation", "jsonVariationDetail", "useFlags", "useLDClient", "withLDConsumer",
+			"getBooleanValue", "getStringValue", "getNumberValue", "getObjectValue",
+			"getBooleanDetails", "getStringDetails", "getNumberDetails", "getObjectDetails",
+			"useFlag", "useBooleanFlagValue", "useStringFlagValue", "useNumberFlagValue", "useObjectFlagValue",
+			"useBooleanFlagDetails", "useStringFlagDetails", "useNumberFlagDetails", "useObjectFlagDetails",
+		},
+	},
+	{
+		Name:          "java",
+		Extensions:    []string{".java"},
+		LineComments:  []string{"//"},
+		BlockComments: cStyleComments,
+		Quotes:        []string{`"""`, `"`, `'`},
+		Keywords:      []string{"final", "static", "public", "private", "protected"},
+		EvaluationMethods: []string{
+			"boolVariation", "stringVariation", "intVariation", "doubleVariation", "jsonValueVariation",
+			"boolVariationDetail", "stringVariationDetail", "intVariationDetail", "doubleVariationDetail", "jsonValueVariationDetail",
+			"getBooleanValue", "getStringValue", "getIntegerValue", "getDoubleValue", "getObjectValue",
+			"getBooleanDetails", "getStringDetails", "getIntegerDetails", "getDoubleDetails", "getObjectDetails",
+		},
+	},
+	{
+		Name:            "kotlin",
+		Extensions:      []string{".kt", ".kts"},
+		LineComments:    []string{"//"},
+		BlockComments:   cStyleComments,
+		Quotes:          []string{`"""`, `"`, `'`},
+		RawQuotes:       []string{`"""`},
+		TypeAnnotations: true,
+		Keywords:        []string{"const", "val", "var", "private", "internal", "public", "protected"},
+		EvaluationMethods: []string{
+			"boolVariation", "stringVariation", "intVariation", "doubleVariation", "jsonValueVariation",
+			"boolVariationDetail", "stringVariationDetail", "intVariationDetail", "doubleVariationDetail", "jsonValueVariationDetail",
+			"getBooleanValue", "getStringValue", "getIntegerValue", "getDoubleValue", "getObjectValue",
+			"getBooleanDetails", "getStringDetails", "getIntegerDetails", "getDoubleDetails", "getObjectDetails",
+		},
+	},
+	{
+		Name:            "python",
+		Extensions:      []string{".py", ".pyi"},
+		LineComments:    []string{"#"},
+		Quotes:          []string{`"""`, `'''`, `"`, `'`},
+		TypeAnnotations: true,
+		EvaluationMethods: []string{
+			"variation", "variation_detail",
+			"get_boolean_value", "get_string_value", "get_integer_value", "get_float_value", "get_object_value",
+			"get_boolean_details", "get_string_details", "get_integer_details", "get_float_details", "get_object_details",
+		},
+	},
+	{
+		Name:          "ruby",
+		Extensions:    []string{".rb", ".rake"},
+		LineComments:  []string{"#"},
+		BlockComments: []BlockComment{{Start: "=begin", End: "=end"}},
+		Quotes:        []string{`"`, `'`},
+		EvaluationMethods: []string{
+			"variation", "variation_detail",
+			"fetch_boolean_value", "fetch_string_value", "fetch_number_value", "fetch_integer_value", "fetch_float_value", "fetch_object_value",
+			"fetch_boolean_details", "fetch_string_details", "fetch_number_details", "fetch_integer_details", "fetch_float_details", "fetch_object_details",
+		},
+	},
+	{
+		Name:          "csharp",
+		Extensions:    []string{".cs"},
+		LineComments:  []string{"//"},
+		BlockComments: cStyleComments,
+		Quotes:        []string{`"""`, `"`, `'`},
+		Keywords:      []string{"const", "static", "readonly", "public", "private", "protected", "internal"},
+		EvaluationMethods: []string{
+			"BoolVariation", "StringVariation", "IntVariation", "FloatVariation", "DoubleVariation", "JsonVariation",
+			"BoolVariationDetail", "StringVariationDetail", "IntVariationDetail", "FloatVariationDetail", "DoubleVariationDetail", "JsonVariationDetail",
+			"GetBooleanValueAsync", "GetStringValueAsync", "GetIntegerValueAsync", "GetDoubleValueAsync", "GetObjectValueAsync",
+			"GetBooleanDetailsAsync", "GetStringDetailsAsync", "GetIntegerDetailsAsync", "GetDoubleDetailsAsync", "GetObjectDetailsAsync",
+		},
+	},
+	{
+		Name:            "swift",
+		Extensions:      []string{".swift"},
+		LineComments:    []string{"//"},
+		BlockComments:   cStyleComments,
+		Quotes:          []string{`"""`, `"`},
+		TypeAnnotations: true,
+		Keywords:        []string{"let", "var", "static", "public", "private", "internal", "fileprivate"},
+		EvaluationMethods: []string{
+			"boolVariation", "stringVariation", "intVariation", "doubleVariation", "jsonVariation",
+			"boolVariationDetail", "stringVariationDetail", "intVariationDetail", "doubleVariationDetail", "jsonVariationDetail",
+			"getBooleanValue", "getStringValue", "getIntegerValue", "getDoubleValue", "getObjectValue",
+			"getBooleanDetails", "getStringDetails", "getIntegerDetails", "getDoubleDetails", "getObjectDetails",
+		},
+	},
+}
+
+func init() {
+	for i, l := range languages {
+		methods := make([]string, 0, len(l.EvaluationMethods))
+		for _, m := range l.EvaluationMethods {
+			methods = append(methods, regexp.QuoteMeta(m))
+		}
+		languages[i].evaluationCall = regexp.MustCompile(`\b(?:` + strings.Join(methods, "|") + `)\s*\(`)
+	}
+}
+
+// HasEvaluation returns true if a line of source code calls an SDK method that evaluates flags
+func (l *Language) HasEvaluation(line string) bool {
+	return l.evaluationCall != nil && l.evaluationCall.MatchString(line)
+}
+
+// ForPath returns the language of a file based on its extension, or nil if the language is not supported
+func ForPath(path string) *Language {
+	ext := strings.ToLower(filepath.Ext(path))
+	for i, l := range languages {
+		for _, e := range l.Extensions {
+			if e == ext {
+				return &languages[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Extensions returns the file extensions of all supported languages
+func Extensions() []string {
+	ret := []string{}
+	for _, l := range languages {
+		ret = append(ret, l.Extensions...)
+	}
+	return ret
+}
+
+func (l *Language) isKeyword(s string) bool {
+	for _, k := range l.Keywords {
+		if k == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Language) isRawQuote(q string) bool {
+	for _, r := range l.RawQuotes {
+		if r == q {
+			return true
+		}
+	}
+	return false
+}
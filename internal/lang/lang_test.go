@@ -0,0 +1,185 @@
+package lang
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForPath(t *testing.T) {
+	assert.Equal(t, "go", ForPath("pkg/flags.go").Name)
+	assert.Equal(t, "javascript", ForPath("src/flags.TSX").Name)
+	assert.Equal(t, "python", ForPath("flags.py").Name)
+	assert.Nil(t, ForPath("README.md"))
+}
+
+func TestTokenize(t *testing.T) {
+	tokens := Tokenize(ForPath("a.go"), "x := `raw\\n` // \"comment\"\n/* block\ncomment */ y = \"a\\\"b\"")
+	require.Len(t, tokens, 8)
+	assert.Equal(t, Token{Kind: String, Text: "`raw\\n`", Value: "raw\\n", Line: 1}, tokens[2])
+	assert.Equal(t, Comment, tokens[3].Kind)
+	assert.Equal(t, Token{Kind: Comment, Text: "/* block\ncomment */", Line: 2}, tokens[4])
+	assert.Equal(t, Token{Kind: Ident, Text: "y", Line: 3}, tokens[5])
+	assert.Equal(t, Token{Kind: String, Text: `"a\"b"`, Value: `a"b`, Line: 3}, tokens[7])
+}
+
+func TestFindStringAssignments(t *testing.T) {
+	specs := []struct {
+		name string
+		path string
+		src  string
+		want []Assignment
+	}{
+		{
+			name: "go",
+			path: "flags.go",
+			src: `package flags
+
+const NEW_CHECKOUT = "new-checkout"
+
+const (
+	DarkMode string = "dark-mode" // comment
+	Banner flags.Key = "banner"
+)
+
+func f() {
+	key := "inline"
+	g(x == "compare")
+	prefixed := "prefix-" + key
+}
+`,
+			want: []Assignment{{"NEW_CHECKOUT", "new-checkout", 3}, {"DarkMode", "dark-mode", 6}, {"Banner", "banner", 7}, {"key", "inline", 11}},
+		},
+		{
+			name: "typescript",
+			path: "flags.ts",
+			src: `export const NEW_CHECKOUT: string = 'new-checkout';
+let darkMode = "dark-mode" as const
+enum Flags { Banner = "banner", }
+this.member = "member"
+call({ option = "option" })
+`,
+			want: []Assignment{{"NEW_CHECKOUT", "new-checkout", 1}, {"darkMode", "dark-mode", 2}, {"Banner", "banner", 3}},
+		},
+		{
+			name: "java",
+			path: "Flags.java",
+			src: `class Flags {
+    public static final String NEW_CHECKOUT = "new-checkout";
+    /* String COMMENTED = "commented"; */
+}`,
+			want: []Assignment{{"NEW_CHECKOUT", "new-checkout", 2}},
+		},
+		{
+			name: "kotlin",
+			path: "Flags.kt",
+			src: `const val NEW_CHECKOUT = "new-checkout"
+val darkMode: String = "dark-mode"
+`,
+			want: []Assignment{{"NEW_CHECKOUT", "new-checkout", 1}, {"darkMode", "dark-mode", 2}},
+		},
+		{
+			name: "python",
+			path: "flags.py",
+			src: `NEW_CHECKOUT = "new-checkout"
+DARK_MODE: str = 'dark-mode'
+# BANNER = "banner"
+client.variation(key="keyword")
+`,
+			want: []Assignment{{"NEW_CHECKOUT", "new-checkout", 1}, {"DARK_MODE", "dark-mode", 2}},
+		},
+		{
+			name: "ruby",
+			path: "flags.rb",
+			src: `NEW_CHECKOUT = "new-checkout"
+=begin
+BANNER = "banner"
+=end
+`,
+			want: []Assignment{{"NEW_CHECKOUT", "new-checkout", 1}},
+		},
+		{
+			name: "csharp",
+			path: "Flags.cs",
+			src:  `public const string NewCheckout = "new-checkout";`,
+			want: []Assignment{{"NewCheckout", "new-checkout", 1}},
+		},
+		{
+			name: "swift",
+			path: "Flags.swift",
+			src:  `static let newCheckout: String = "new-checkout"`,
+			want: []Assignment{{"newCheckout", "new-checkout", 1}},
+		},
+	}
+
+	for _, tt := range specs {
+		t.Run(tt.name, func(t *testing.T) {
+			l := ForPath(tt.path)
+			require.NotNil(t, l)
+			assert.Equal(t, tt.want, FindStringAssignments(l, tt.src))
+		})
+	}
+}
+
+func TestHasEvaluation(t *testing.T) {
+	specs := []struct {
+		path string
+		line string
+		want bool
+	}{
+		{"main.go", `client.BoolVariation("new-checkout", ctx, false)`, true},
+		{"main.go", `client.BoolVariationX("new-checkout")`, false},
+		{"app.tsx", `const { newCheckout } = useFlags()`, true},
+		{"app.ts", `await client.getBooleanValue('new-checkout', false)`, true},
+		{"app.py", `client.variation ("new-checkout", context, False)`, true},
+		{"app.py", `flags = ["new-checkout"]`, false},
+		{"app.rb", `client.fetch_boolean_value("new-checkout", false)`, true},
+		{"App.cs", `await client.GetBooleanValueAsync("new-checkout", false)`, true},
+		{"App.swift", `client.boolVariation(forKey: "new-checkout", defaultValue: false)`, true},
+	}
+
+	for _, tt := range specs {
+		t.Run(tt.line, func(t *testing.T) {
+			l := ForPath(tt.path)
+			require.NotNil(t, l)
+			assert.Equal(t, tt.want, l.HasEvaluation(tt.line))
+		})
+	}
+}
+
+func TestCommentLines(t *testing.T) {
+	src := `// new-checkout
+/* new-checkout
+   new-checkout */
+x := "new-checkout" // new-checkout
+/* a */ y := 1
+`
+	assert.Equal(t, map[int]bool{1: true, 2: true, 3: true}, CommentLines(ForPath("main.go"), src))
+}
+
+func TestEvaluatedKeys(t *testing.T) {
+	specs := []struct {
+		path string
+		line string
+		want []string
+	}{
+		{"main.go", `client.BoolVariation("new-checkout", ctx, false)`, []string{"new-checkout"}},
+		{"main.go", `client.BooleanValue(ctx, "new-checkout", false, evalCtx)`, []string{"new-checkout"}},
+		{"main.go", `client.BoolVariation(key, ctx, false)`, []string{}},
+		{"app.ts", "client.variation(`exp-${name}`, context, false)", []string{}},
+		{"app.ts", `client.variation(getKey("a"), context, false) || client.boolVariation('dark-mode', ctx, false)`, []string{"dark-mode"}},
+		{"app.ts", `useFlags()`, []string{}},
+		{"app.py", `client.variation(key="new-checkout", context=ctx, default=False)`, []string{"new-checkout"}},
+		{"App.swift", `client.boolVariation(forKey: "new-checkout", defaultValue: false)`, []string{"new-checkout"}},
+		{"App.java", `client.boolVariation(context, false)`, []string{}},
+	}
+
+	for _, tt := range specs {
+		t.Run(tt.line, func(t *testing.T) {
+			l := ForPath(tt.path)
+			require.NotNil(t, l)
+			assert.Equal(t, tt.want, l.EvaluatedKeys(tt.line))
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package lang
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type TokenKind int
+
+const (
+	Ident TokenKind = iota
+	String
+	Number
+	Punct
+	Comment
+)
+
+type Token struct {
+	Kind TokenKind
+	// Source text of the token, including quotes and comment markers
+	Text string
+	// Contents of a string literal with escape sequences resolved
+	Value string
+	// 1-based line number where the token starts
+	Line int
+}
+
+const operatorChars = "=!<>:+-*/%&|^?~"
+
+// Tokenize splits source code into tokens. Whitespace is discarded, and unterminated strings and comments end at the end of the source.
+func Tokenize(l *Language, src string) []Token {
+	tokens := []Token{}
+	line := 1
+	i := 0
+	emit := func(kind TokenKind, end int, value string) {
+		text := src[i:end]
+		tokens = append(tokens, Token{Kind: kind, Text: text, Value: value, Line: line})
+		line += strings.Count(text, "\n")
+		i = end
+	}
+
+	for i < len(src) {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		rest := src[i:]
+
+		if unicode.IsSpace(r) {
+			if r == '\n' {
+				line++
+			}
+			i += size
+			continue
+		}
+		if prefix := matchPrefix(rest, l.LineComments); prefix != "" {
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			emit(Comment, i+end, "")
+			continue
+		}
+		if block, ok := matchBlockComment(rest, l.BlockComments); ok {
+			end := strings.Index(rest[len(block.Start):], block.End)
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += len(block.Start) + len(block.End)
+			}
+			emit(Comment, i+end, "")
+			continue
+		}
+		if quote := matchPrefix(rest, l.Quotes); quote != "" {
+			end, value := scanString(rest, quote, l.isRawQuote(quote))
+			emit(String, i+end, value)
+			continue
+		}
+
+		switch {
+		case isIdentStart(r):
+			end := size
+			for end < len(rest) {
+				next, nextSize := utf8.DecodeRuneInString(rest[end:])
+				if !isIdentStart(next) && !unicode.IsDigit(next) {
+					break
+				}
+				end += nextSize
+			}
+			emit(Ident, i+end, "")
+		case unicode.IsDigit(r):
+			end := size
+			for end < len(rest) && (isAlphanumeric(rest[end]) || rest[end] == '.' || rest[end] == '_') {
+				end++
+			}
+			emit(Number, i+end, "")
+		case strings.ContainsRune(operatorChars, r):
+			end := size
+			for end < len(rest) && strings.IndexByte(operatorChars, rest[end]) >= 0 {
+				end++
+			}
+			emit(Punct, i+end, "")
+		default:
+			emit(Punct, i+size, "")
+		}
+	}
+	return tokens
+}
+
+// scanString returns the length of the string literal at the start of src, and its contents
+func scanString(src, quote string, raw bool) (int, string) {
+	multiline := len(quote) > 1 || quote == "`"
+	var value strings.Builder
+	i := len(quote)
+	for i < len(src) {
+		if strings.HasPrefix(src[i:], quote) {
+			return i + len(quote), value.String()
+		}
+		c := src[i]
+		if c == '\n' && !multiline {
+			return i, value.String()
+		}
+		if c == '\\' && !raw && i+1 < len(src) {
+			value.WriteString(unescape(src[i+1]))
+			i += 2
+			continue
+		}
+		value.WriteByte(c)
+		i++
+	}
+	return len(src), value.String()
+}
+
+func unescape(c byte) string {
+	switch c {
+	case 'n':
+		return "\n"
+	case 't':
+		return "\t"
+	case 'r':
+		return "\r"
+	}
+	return string(c)
+}
+
+func matchPrefix(s string, prefixes []string) string {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return p
+		}
+	}
+	return ""
+}
+
+func matchBlockComment(s string, blocks []BlockComment) (BlockComment, bool) {
+	for _, b := range blocks {
+		if strings.HasPrefix(s, b.Start) {
+			return b, true
+		}
+	}
+	return BlockComment{}, false
+}
+
+func isIdentStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_' || r == '$'
+}
+
+func isAlphanumeric(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
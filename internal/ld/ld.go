@@ -61,6 +61,7 @@ var (
 	RateLimitExceededErr              = errors.New("rate limit exceeded")
 	InternalServiceErr                = errors.New("internal service error")
 	ServiceUnavailableErr             = errors.New("service unavailable")
+	ForbiddenErr                      = errors.New("forbidden, check the role of your LaunchDarkly access token")
 	BranchUpdateSequenceIdConflictErr = errors.New("updateSequenceId conflict")
 	RepositoryDisabledErr             = newConfigurationError("repository is disabled")
 	UnauthorizedErr                   = newConfigurationError("unauthorized, check your LaunchDarkly access token")
@@ -68,6 +69,7 @@ var (
 )
 
 // IsTransient returns true if the error returned by the LaunchDarkly API is either unexpected, or unable to be resolved by the user.
+// ForbiddenErr is transient, so scans with ignoreServiceErrors do not fail when the role of the access token is missing a permission.
 func IsTransient(err error) bool {
 	var e ConfigurationError
 	return !errors.As(err, &e)
@@ -127,6 +129,22 @@ func (c ApiClient) getPathFromLink(path string) string {
 }
 
 func (c ApiClient) GetFlagKeyList(projKey string, skipArchivedFlags bool) ([]string, error) {
+	flags, err := c.GetFlagList(projKey, skipArchivedFlags, "")
+	if err != nil {
+		return nil, err
+	}
+
+	flagKeys := make([]string, 0, len(flags))
+	for _, flag := range flags {
+		flagKeys = append(flagKeys, flag.Key)
+	}
+
+	return flagKeys, nil
+}
+
+// GetFlagList returns the flags of a project, including archived flags unless skipArchivedFlags is set. If filter is not empty,
+// it is added to the filter of each request, e.g. `type:temporary`
+func (c ApiClient) GetFlagList(projKey string, skipArchivedFlags bool, filter string) ([]ldapi.FeatureFlag, error) {
 	env, err := c.getProjectEnvironment(projKey)
 	if err != nil {
 		return nil, err
@@ -136,6 +154,9 @@ func (c ApiClient) GetFlagKeyList(projKey string, skipArchivedFlags bool) ([]str
 	if env != nil {
 		params.Add("env", env.Key)
 	}
+	if filter != "" {
+		params.Set("filter", filter)
+	}
 	activeFlags, err := c.getFlags(projKey, params)
 	if err != nil {
 		return nil, err
@@ -143,14 +164,14 @@ func (c ApiClient) GetFlagKeyList(projKey string, skipArchivedFlags bool) ([]str
 
 	// If we only want live flags, return them now
 	if skipArchivedFlags {
-		flagKeys := make([]string, 0, len(activeFlags))
-		for _, flag := range activeFlags {
-			flagKeys = append(flagKeys, flag.Key)
-		}
-		return flagKeys, nil
+		return activeFlags, nil
 	}
 
-	params.Add("filter", "state:archived")
+	archivedFilter := "state:archived"
+	if filter != "" {
+		archivedFilter += "," + filter
+	}
+	params.Set("filter", archivedFilter)
 	archivedFlags, err := c.getFlags(projKey, params)
 	if err != nil {
 		return nil, err
@@ -159,12 +180,29 @@ func (c ApiClient) GetFlagKeyList(projKey string, skipArchivedFlags bool) ([]str
 	flags = append(flags, activeFlags...)
 	flags = append(flags, archivedFlags...)
 
-	flagKeys := make([]string, 0, len(flags))
-	for _, flag := range flags {
-		flagKeys = append(flagKeys, flag.Key)
+	return flags, nil
+}
+
+// GetProject returns NotFoundErr if the project does not exist
+func (c ApiClient) GetProject(projKey string) error {
+	req, err := h.NewRequest(http.MethodGet, c.getPath(fmt.Sprintf("/projects/%s", projKey)), nil) //nolint:perfsprint
+	if err != nil {
+		return err
 	}
 
-	return flagKeys, nil
+	if res, err := c.do(req); err != nil {
+		return err
+	} else if res != nil {
+		defer res.Body.Close()
+	}
+
+	return nil
+}
+
+// CheckCodeReferenceRepositoryUpdate sends an empty update of an existing code reference repository,
+// to check that the access token is allowed to update its code references without changing them
+func (c ApiClient) CheckCodeReferenceRepositoryUpdate(repo RepoParams) error {
+	return c.patchCodeReferenceRepository(repo, repo)
 }
 
 // Get the first environment we can find for a project
@@ -285,7 +323,8 @@ func (c ApiClient) patchCodeReferenceRepository(currentRepo, repo RepoParams) er
 	return nil
 }
 
-func (c ApiClient) getCodeReferenceRepository(name string) (*RepoRep, error) {
+// GetCodeReferenceRepository returns the code reference repository with the given name, or NotFoundErr if it does not exist
+func (c ApiClient) GetCodeReferenceRepository(name string) (*RepoRep, error) {
 	req, err := h.NewRequest("GET", c.getPath(fmt.Sprintf("%s/%s", reposPath, name)), nil)
 	if err != nil {
 		return nil, err
@@ -311,6 +350,68 @@ func (c ApiClient) getCodeReferenceRepository(name string) (*RepoRep, error) {
 	return &repo, err
 }
 
+// GetCodeReferenceRepositories returns every code reference repository of the account
+func (c ApiClient) GetCodeReferenceRepositories() ([]RepoRep, error) {
+	req, err := h.NewRequest("GET", c.getPath(reposPath), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.do(req)
+	if err != nil || res == nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	resBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var repos RepoCollection
+	err = json.Unmarshal(resBytes, &repos)
+	if err != nil {
+		return nil, err
+	}
+	return repos.Items, err
+}
+
+// SetCodeReferenceRepositoryEnabled enables or disables a code reference repository. Scans of a disabled repository fail with RepositoryDisabledErr
+func (c ApiClient) SetCodeReferenceRepositoryEnabled(name string, enabled bool) error {
+	patch, err := json.Marshal(map[string]bool{"enabled": enabled})
+	if err != nil {
+		return err
+	}
+
+	req, err := h.NewRequest("PATCH", c.getPath(fmt.Sprintf("%s/%s", reposPath, name)), bytes.NewBuffer(patch))
+	if err != nil {
+		return err
+	}
+
+	if res, err := c.do(req); err != nil {
+		return err
+	} else if res != nil {
+		defer res.Body.Close()
+	}
+
+	return nil
+}
+
+// DeleteCodeReferenceRepository deletes a code reference repository and the code references of all of its branches
+func (c ApiClient) DeleteCodeReferenceRepository(name string) error {
+	req, err := h.NewRequest("DELETE", c.getPath(fmt.Sprintf("%s/%s", reposPath, name)), nil)
+	if err != nil {
+		return err
+	}
+
+	if res, err := c.do(req); err != nil {
+		return err
+	} else if res != nil {
+		defer res.Body.Close()
+	}
+
+	return nil
+}
+
 func (c ApiClient) GetCodeReferenceRepositoryBranches(repoName string) ([]BranchRep, error) {
 	req, err := h.NewRequest("GET", c.getPath(fmt.Sprintf("%s/%s/branches", reposPath, repoName)), nil)
 	if err != nil {
@@ -358,7 +459,7 @@ func (c ApiClient) postCodeReferenceRepository(repo RepoParams) error {
 }
 
 func (c ApiClient) MaybeUpsertCodeReferenceRepository(repo RepoParams) error {
-	currentRepo, err := c.getCodeReferenceRepository(repo.Name)
+	currentRepo, err := c.GetCodeReferenceRepository(repo.Name)
 	if err != nil && err != NotFoundErr {
 		return fmt.Errorf("error retrieving repository: %w", err)
 	}
@@ -527,6 +628,8 @@ func fallbackErrorForStatus(code int) error {
 		return errors.New("bad request")
 	case http.StatusUnauthorized:
 		return UnauthorizedErr
+	case http.StatusForbidden:
+		return ForbiddenErr
 	case http.StatusNotFound:
 		return NotFoundErr
 	case http.StatusConflict:
@@ -563,6 +666,10 @@ type RepoRep struct {
 	Enabled           bool   `json:"enabled,omitempty"`
 }
 
+type RepoCollection struct {
+	Items []RepoRep `json:"items"`
+}
+
 type BranchCollection struct {
 	Items []BranchRep `json:"items"`
 }
@@ -623,7 +730,7 @@ func (b BranchRep) WriteToCSV(outDir, projKey, repo, sha string) (path string, e
 		return false
 	})
 
-	records = append([][]string{{"flagKey", "projKey", "path", "startingLineNumber", "lines", "aliases", "contentHash"}}, records...)
+	records = append([][]string{{"flagKey", "projKey", "path", "startingLineNumber", "lines", "aliases", "contentHash", "kind", "annotated", "confidence", "matchedText"}}, records...)
 	return path, w.WriteAll(records)
 }
 
@@ -635,7 +742,7 @@ type ReferenceHunksRep struct {
 func (r ReferenceHunksRep) toRecords() [][]string {
 	ret := make([][]string, 0, len(r.Hunks))
 	for _, hunk := range r.Hunks {
-		ret = append(ret, []string{hunk.FlagKey, hunk.ProjKey, r.Path, strconv.FormatInt(int64(hunk.StartingLineNumber), 10), hunk.Lines, strings.Join(hunk.Aliases, " "), hunk.ContentHash})
+		ret = append(ret, []string{hunk.FlagKey, hunk.ProjKey, r.Path, strconv.FormatInt(int64(hunk.StartingLineNumber), 10), hunk.Lines, strings.Join(hunk.Aliases, " "), hunk.ContentHash, hunk.Kind, strconv.FormatBool(hunk.Annotated), hunk.Confidence, strings.Join(hunk.MatchedText, " ")})
 	}
 	return ret
 }
@@ -646,9 +753,25 @@ type HunkRep struct {
 	ProjKey            string   `json:"projKey"`
 	FlagKey            string   `json:"flagKey"`
 	Aliases            []string `json:"aliases,omitempty"`
+	MatchedText        []string `json:"matchedText,omitempty"` // Text that matched the flag key when it differs from the flag key
 	ContentHash        string   `json:"contentHash,omitempty"`
+	Kind               string   `json:"kind,omitempty"`
+	Annotated          bool     `json:"annotated,omitempty"`  // Whether the reference was declared by an `ld-flag` annotation
+	Confidence         string   `json:"confidence,omitempty"` // LowConfidence for references inferred from key templates, otherwise empty
 }
 
+// LowConfidence is the confidence of references to flags with keys matching a key template, rather than the exact flag key
+const LowConfidence = "low"
+
+// Kinds of code references
+const (
+	EvaluationKind  = "evaluation"  // SDK call that evaluates a flag
+	DeclarationKind = "declaration" // flag key assigned to an identifier
+	TestKind        = "test"        // reference in a test file
+	ConfigKind      = "config"      // reference in a configuration file
+	CommentKind     = "comment"     // reference in a comment
+)
+
 // Returns the number of lines overlapping between the receiver (h) and the parameter (hr) hunkreps
 // The return value will be negative if the hunks do not overlap
 func (h HunkRep) Overlap(hr HunkRep) int {
@@ -713,6 +836,34 @@ func (b BranchRep) CountByProjectAndFlag(matcher [][]string, projects []string)
 	return refCountByFlag
 }
 
+// WithoutKind returns a copy of the branch without hunks of the given kind
+func (b BranchRep) WithoutKind(kind string) BranchRep {
+	return b.filterHunks(func(hunk HunkRep) bool { return hunk.Kind != kind })
+}
+
+// WithoutConfidence returns a copy of the branch without hunks of the given confidence
+func (b BranchRep) WithoutConfidence(confidence string) BranchRep {
+	return b.filterHunks(func(hunk HunkRep) bool { return hunk.Confidence != confidence })
+}
+
+// filterHunks returns a copy of the branch with only the hunks to keep
+func (b BranchRep) filterHunks(keep func(HunkRep) bool) BranchRep {
+	references := make([]ReferenceHunksRep, 0, len(b.References))
+	for _, ref := range b.References {
+		hunks := make([]HunkRep, 0, len(ref.Hunks))
+		for _, hunk := range ref.Hunks {
+			if keep(hunk) {
+				hunks = append(hunks, hunk)
+			}
+		}
+		if len(hunks) > 0 {
+			references = append(references, ReferenceHunksRep{Path: ref.Path, Hunks: hunks})
+		}
+	}
+	b.References = references
+	return b
+}
+
 func (b BranchRep) PrintReferenceCountTable() {
 	data := tableData{}
 
@@ -1,6 +1,8 @@
 package ld
 
 import (
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -64,12 +66,49 @@ func TestGetCodeReferenceRepository(t *testing.T) {
 
 			retryMax := 0
 			client := InitApiClient(ApiOptions{ApiKey: "api-x", ProjKey: "default", BaseUri: testServer.URL, RetryMax: &retryMax})
-			_, err := client.getCodeReferenceRepository("test")
+			_, err := client.GetCodeReferenceRepository("test")
 			require.Equal(t, tt.expectedErr, err)
 		})
 	}
 }
 
+func TestGetProject(t *testing.T) {
+	specs := []struct {
+		name           string
+		responseStatus int
+		expectedErr    error
+	}{
+		{"succeeds", 200, nil},
+		{"fails on not found", 404, NotFoundErr},
+		{"fails on unauthorized", 401, UnauthorizedErr},
+	}
+	for _, tt := range specs {
+		t.Run(tt.name, func(t *testing.T) {
+			testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				require.Equal(t, "/api/v2/projects/default", req.URL.Path)
+				res.WriteHeader(tt.responseStatus)
+			}))
+			defer testServer.Close()
+
+			retryMax := 0
+			client := InitApiClient(ApiOptions{ApiKey: "api-x", BaseUri: testServer.URL, RetryMax: &retryMax})
+			require.Equal(t, tt.expectedErr, client.GetProject("default"))
+		})
+	}
+}
+
+func TestReferenceHunksRep_toRecords(t *testing.T) {
+	ref := ReferenceHunksRep{Path: "a.go", Hunks: []HunkRep{{FlagKey: "new-checkout", ProjKey: "default", StartingLineNumber: 3, MatchedText: []string{"NEW-CHECKOUT", "New-Checkout"}}}}
+	require.Equal(t, [][]string{{"new-checkout", "default", "a.go", "3", "", "", "", "", "false", "", "NEW-CHECKOUT New-Checkout"}}, ref.toRecords())
+}
+
+func TestIsTransient(t *testing.T) {
+	require.True(t, IsTransient(ForbiddenErr))
+	require.True(t, IsTransient(fmt.Errorf("wrapped: %w", ServiceUnavailableErr)))
+	require.False(t, IsTransient(UnauthorizedErr))
+	require.False(t, IsTransient(RepositoryDisabledErr))
+}
+
 func TestPatchCodeReferenceRepository(t *testing.T) {
 	specs := []struct {
 		name           string
@@ -80,6 +119,7 @@ func TestPatchCodeReferenceRepository(t *testing.T) {
 	}{
 		{"succeeds", RepoParams{Url: "github.com"}, RepoParams{Url: "bitbucket.com"}, 200, nil},
 		{"fails on 404", RepoParams{Url: "github.com"}, RepoParams{Url: "bitbucket.com"}, 404, NotFoundErr},
+		{"fails on 403", RepoParams{Url: "github.com"}, RepoParams{Url: "github.com"}, 403, ForbiddenErr},
 	}
 	for _, tt := range specs {
 		t.Run(tt.name, func(t *testing.T) {
@@ -171,6 +211,92 @@ func TestGetCodeReferenceRepositoryBranches(t *testing.T) {
 	}
 }
 
+func TestGetCodeReferenceRepositories(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "/api/v2/code-refs/repositories", req.URL.Path)
+		_, err := res.Write([]byte(`{"items":[{"name":"web","type":"github","enabled":true},{"name":"mobile","type":"custom"}]}`))
+		require.NoError(t, err)
+	}))
+	defer testServer.Close()
+
+	retryMax := 0
+	client := InitApiClient(ApiOptions{ApiKey: "api-x", BaseUri: testServer.URL, RetryMax: &retryMax})
+	repos, err := client.GetCodeReferenceRepositories()
+	require.NoError(t, err)
+	require.Equal(t, []RepoRep{{Name: "web", Type: "github", Enabled: true}, {Name: "mobile", Type: "custom"}}, repos)
+}
+
+func TestSetCodeReferenceRepositoryEnabled(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			require.Equal(t, "PATCH", req.Method)
+			require.Equal(t, "/api/v2/code-refs/repositories/web", req.URL.Path)
+			body, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			require.JSONEq(t, fmt.Sprintf(`{"enabled":%t}`, enabled), string(body))
+		}))
+
+		retryMax := 0
+		client := InitApiClient(ApiOptions{ApiKey: "api-x", BaseUri: testServer.URL, RetryMax: &retryMax})
+		require.NoError(t, client.SetCodeReferenceRepositoryEnabled("web", enabled))
+		testServer.Close()
+	}
+}
+
+func TestDeleteCodeReferenceRepository(t *testing.T) {
+	specs := []struct {
+		name           string
+		responseStatus int
+		expectedErr    error
+	}{
+		{"succeeds", 204, nil},
+		{"fails on not found", 404, NotFoundErr},
+	}
+	for _, tt := range specs {
+		t.Run(tt.name, func(t *testing.T) {
+			testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				require.Equal(t, "DELETE", req.Method)
+				require.Equal(t, "/api/v2/code-refs/repositories/web", req.URL.Path)
+				res.WriteHeader(tt.responseStatus)
+			}))
+			defer testServer.Close()
+
+			retryMax := 0
+			client := InitApiClient(ApiOptions{ApiKey: "api-x", BaseUri: testServer.URL, RetryMax: &retryMax})
+			require.Equal(t, tt.expectedErr, client.DeleteCodeReferenceRepository("web"))
+		})
+	}
+}
+
+func TestGetFlagList(t *testing.T) {
+	filters := []string{}
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/api/v2/projects/default/environments" {
+			_, err := res.Write([]byte(`{"items":[{"key":"production"}]}`))
+			require.NoError(t, err)
+			return
+		}
+		filter := req.URL.Query().Get("filter")
+		filters = append(filters, filter)
+		key := "active-flag"
+		if filter == "state:archived,type:temporary" {
+			key = "archived-flag"
+		}
+		_, err := res.Write([]byte(`{"items":[{"key":"` + key + `","temporary":true}],"totalCount":1}`))
+		require.NoError(t, err)
+	}))
+	defer testServer.Close()
+
+	retryMax := 0
+	client := InitApiClient(ApiOptions{ApiKey: "api-x", ProjKey: "default", BaseUri: testServer.URL, RetryMax: &retryMax})
+	flags, err := client.GetFlagList("default", false, "type:temporary")
+	require.NoError(t, err)
+	require.Len(t, flags, 2)
+	require.Equal(t, "active-flag", flags[0].Key)
+	require.Equal(t, "archived-flag", flags[1].Key)
+	require.Equal(t, []string{"type:temporary", "state:archived,type:temporary"}, filters)
+}
+
 func TestCountAll(t *testing.T) {
 	flagKey := "testFlag"
 
@@ -197,6 +323,32 @@ func TestCountAll(t *testing.T) {
 
 }
 
+func TestWithoutKind(t *testing.T) {
+	evaluation := HunkRep{FlagKey: "a", Kind: EvaluationKind}
+	comment := HunkRep{FlagKey: "b", Kind: CommentKind}
+	b := BranchRep{
+		Name: "main",
+		References: []ReferenceHunksRep{
+			{Path: "a.go", Hunks: []HunkRep{evaluation, comment}},
+			{Path: "b.go", Hunks: []HunkRep{comment}},
+		},
+	}
+	got := b.WithoutKind(CommentKind)
+	require.Equal(t, []ReferenceHunksRep{{Path: "a.go", Hunks: []HunkRep{evaluation}}}, got.References)
+	require.Len(t, b.References, 2)
+}
+
+func TestWithoutConfidence(t *testing.T) {
+	exact := HunkRep{FlagKey: "checkout-a"}
+	template := HunkRep{FlagKey: "checkout-b", Confidence: LowConfidence}
+	b := BranchRep{
+		Name:       "main",
+		References: []ReferenceHunksRep{{Path: "a.go", Hunks: []HunkRep{exact, template}}},
+	}
+	got := b.WithoutConfidence(LowConfidence)
+	require.Equal(t, []ReferenceHunksRep{{Path: "a.go", Hunks: []HunkRep{exact}}}, got.References)
+}
+
 func TestCountByProjectAndFlag(t *testing.T) {
 	flagKey := "testFlag"
 	notFoundKey := "notFoundFlag"
@@ -5,13 +5,15 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
 )
 
 type AliasType string
 
 func (a AliasType) IsValid() error {
 	switch a.Canonical() {
-	case Literal, CamelCase, PascalCase, SnakeCase, UpperSnakeCase, KebabCase, DotCase, FilePattern, Command:
+	case Literal, CamelCase, PascalCase, SnakeCase, UpperSnakeCase, KebabCase, DotCase, FilePattern, Command, Auto:
 		return nil
 	}
 	return fmt.Errorf("'%s' is not a valid alias type", a)
@@ -25,6 +27,15 @@ func (a AliasType) Canonical() AliasType {
 	return AliasType(a.String())
 }
 
+// IsNamingConvention reports whether aliases of this type are generated by transposing flag keys to a casing convention
+func (a AliasType) IsNamingConvention() bool {
+	switch a.Canonical() {
+	case CamelCase, PascalCase, SnakeCase, UpperSnakeCase, KebabCase, DotCase:
+		return true
+	}
+	return false
+}
+
 func (a AliasType) unexpectedFieldErr(field string) error {
 	return fmt.Errorf("unexpected field for %s alias: '%s'", a, field)
 }
@@ -42,6 +53,80 @@ const (
 	FilePattern AliasType = "filepattern"
 
 	Command AliasType = "command"
+
+	Auto AliasType = "auto"
+)
+
+const (
+	// FileScope limits each alias to the file that defined it
+	FileScope = "file"
+	// PackageScope limits each alias to the files in the directory of the file that defined it
+	PackageScope = "package"
+)
+
+// CaseOptions configures how flag keys are split into words and joined for naming convention aliases
+type CaseOptions struct {
+	// Words written in uppercase in camelCase and PascalCase aliases, e.g. `API` generates `enableAPI` instead of `enableApi`
+	Acronyms []string `mapstructure:"acronyms,omitempty"`
+	// How numbers are joined to the preceding word: `attach` (`api_v2`) or `split` (`api_v_2`). Defaults to `attach`
+	Numbers NumberCase `mapstructure:"numbers,omitempty"`
+	// If set to `true`, every spelling variant is generated for each naming convention: with and without acronyms, with attached
+	// and split numbers, and the spelling generated when no case options are configured
+	Variants bool `mapstructure:"variants,omitempty"`
+}
+
+var validAcronym = regexp.MustCompile("^[A-Za-z0-9]+$")
+
+func (c *CaseOptions) IsValid() error {
+	if err := c.Numbers.IsValid(); err != nil {
+		return err
+	}
+	for _, acronym := range c.Acronyms {
+		if !validAcronym.MatchString(acronym) {
+			return fmt.Errorf(`invalid value %q for "caseOptions.acronyms": acronyms may only contain letters and numbers`, acronym)
+		}
+	}
+	return nil
+}
+
+type NumberCase string
+
+func (n NumberCase) IsValid() error {
+	switch n.Canonical() {
+	case "", AttachNumbers, SplitNumbers:
+		return nil
+	}
+	return fmt.Errorf(`invalid value %q for "caseOptions.numbers": must be %s or %s`, n, AttachNumbers, SplitNumbers)
+}
+
+func (n NumberCase) Canonical() NumberCase {
+	return NumberCase(strings.ToLower(string(n)))
+}
+
+const (
+	AttachNumbers NumberCase = "attach"
+	SplitNumbers  NumberCase = "split"
+)
+
+type AliasCollisionPolicy string
+
+func (p AliasCollisionPolicy) IsValid() error {
+	switch p.Canonical() {
+	case AllowCollisions, WarnCollisions, DropCollisions, ErrorCollisions:
+		return nil
+	}
+	return fmt.Errorf(`invalid value %q for "aliasCollisions": must be %s, %s, %s, or %s`, p, AllowCollisions, WarnCollisions, DropCollisions, ErrorCollisions)
+}
+
+func (p AliasCollisionPolicy) Canonical() AliasCollisionPolicy {
+	return AliasCollisionPolicy(strings.ToLower(string(p)))
+}
+
+const (
+	AllowCollisions AliasCollisionPolicy = "allow"
+	WarnCollisions  AliasCollisionPolicy = "warn"
+	DropCollisions  AliasCollisionPolicy = "drop"
+	ErrorCollisions AliasCollisionPolicy = "error"
 )
 
 // Alias is a catch-all type for alias configurations
@@ -52,13 +137,32 @@ type Alias struct {
 	// Literal
 	Flags map[string][]string `mapstructure:"flags,omitempty"`
 
-	// FilePattern
+	// FilePattern and Auto
 	Paths    []string `mapstructure:"paths,omitempty"`
 	Patterns []string `mapstructure:"patterns,omitempty"`
 
 	// Command
 	Command *string `mapstructure:"command,omitempty"`
 	Timeout *int64  `mapstructure:"timeout,omitempty"`
+
+	// Naming conventions
+	// Overrides the top-level case options for this alias
+	CaseOptions *CaseOptions `mapstructure:"caseOptions,omitempty"`
+
+	// FilePattern, Command, and Auto
+	// Limits where aliases are searched for: `file` for the file that defined the alias, `package` for the directory of that file,
+	// or a glob relative to the repository root
+	Scope string `mapstructure:"scope,omitempty"`
+}
+
+// CanonicalScope returns the scope with `file` and `package` in lowercase, like other enumerated options. Globs are case-sensitive and
+// returned unchanged.
+func (a Alias) CanonicalScope() string {
+	switch scope := strings.ToLower(a.Scope); scope {
+	case FileScope, PackageScope:
+		return scope
+	}
+	return a.Scope
 }
 
 func (a *Alias) IsValid() error {
@@ -93,6 +197,35 @@ func (a *Alias) IsValid() error {
 		if a.Timeout != nil && *a.Timeout < 0 {
 			return errors.New("field 'timeout' must be >= 0")
 		}
+	case Auto:
+		if len(a.Patterns) > 0 {
+			return a.Type.unexpectedFieldErr("patterns")
+		}
+		for _, path := range a.Paths {
+			if !doublestar.ValidatePattern(path) {
+				return fmt.Errorf("invalid glob for field 'paths': '%s'", path)
+			}
+		}
+	}
+
+	if a.CaseOptions != nil {
+		if !a.Type.IsNamingConvention() {
+			return a.Type.unexpectedFieldErr("caseOptions")
+		}
+		if err := a.CaseOptions.IsValid(); err != nil {
+			return err
+		}
+	}
+
+	if a.Scope != "" {
+		switch a.Type.Canonical() {
+		case FilePattern, Command, Auto:
+			if scope := a.CanonicalScope(); scope != FileScope && scope != PackageScope && !doublestar.ValidatePattern(scope) {
+				return fmt.Errorf("invalid glob for field 'scope': '%s'", a.Scope)
+			}
+		default:
+			return a.Type.unexpectedFieldErr("scope")
+		}
 	}
 
 	// Validate unexpected fields
@@ -0,0 +1,59 @@
+package options
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// FlagFilter limits the flags that are searched for to the flags matching every configured condition
+type FlagFilter struct {
+	// Only flags with at least one of these tags
+	Tags []string `mapstructure:"tags"`
+	// Regular expressions. If set, only flags with a key matching at least one of them
+	Include []string `mapstructure:"include"`
+	// Regular expressions. Flags with a key matching any of them are not searched for
+	Exclude []string `mapstructure:"exclude"`
+	// Only temporary or permanent flags
+	Type FlagType `mapstructure:"type"`
+	// Only flags maintained by one of these teams
+	MaintainerTeams []string `mapstructure:"maintainerTeams"`
+}
+
+type FlagType string
+
+func (t FlagType) Canonical() FlagType {
+	return FlagType(strings.ToLower(string(t)))
+}
+
+const (
+	TemporaryFlags FlagType = "temporary"
+	PermanentFlags FlagType = "permanent"
+)
+
+func (f FlagFilter) validate(field string) error {
+	switch f.Type.Canonical() {
+	case "", TemporaryFlags, PermanentFlags:
+	default:
+		return fmt.Errorf(`invalid value %q for "%s.type": must be %s or %s`, f.Type, field, TemporaryFlags, PermanentFlags)
+	}
+	for i, pattern := range f.Include {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf(`invalid value %q for "%s.include[%d]": %v`, pattern, field, i, err)
+		}
+	}
+	for i, pattern := range f.Exclude {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf(`invalid value %q for "%s.exclude[%d]": %v`, pattern, field, i, err)
+		}
+	}
+	return nil
+}
+
+// ProjectFlagFilter returns the flag filter configured for the project, or the top-level flag filter
+func (o Options) ProjectFlagFilter(project Project) FlagFilter {
+	if project.FlagFilter != nil {
+		return *project.FlagFilter
+	}
+	return o.FlagFilter
+}
@@ -15,6 +15,13 @@ var flags = []flag{
 		defaultValue: "",
 		usage:        "LaunchDarkly personal access token with write-level access.",
 	},
+	{
+		name:         "aliasCollisions",
+		defaultValue: "allow",
+		usage: `How to handle aliases that match more than one flag. Acceptable values:
+allow|warn|drop|error. If "drop", colliding aliases will not be searched for. If "error",
+the scan will fail when colliding aliases are found.`,
+	},
 	{
 		name:         "allowTags",
 		defaultValue: false,
@@ -33,6 +40,20 @@ var flags = []flag{
 		usage: `The currently checked out branch. If not provided, branch
 name will be auto-detected. Provide this option when using CI systems that
 leave the repository in a detached HEAD state.`,
+	},
+	{
+		name:         "caseInsensitive",
+		defaultValue: false,
+		usage: `Enables case-insensitive matching of flag keys and aliases. References whose
+text differs from the flag key are reported with the text that matched.`,
+	},
+	{
+		name:         "comments",
+		defaultValue: "include",
+		usage: `How to handle references on lines that only contain comments. Acceptable values:
+include|tag|ignore. If "ignore", these references will not be reported. If "tag", hunks with
+only these references will have the kind "comment", and will not prevent flags from being
+reported as removed.`,
 	},
 	{
 		name:         "commitUrlTemplate",
@@ -70,6 +91,13 @@ If not provided, will fallback to 'main'.`,
 		defaultValue: "",
 		usage:        "Path to existing checkout of the repository.",
 	},
+	{
+		name:         "discover",
+		defaultValue: false,
+		usage: `If enabled, every .launchdarkly/coderefs.yaml file below dir is used to search its
+subdirectory, with options not set in the file inherited from the root configuration. Each
+subdirectory must have its own repoName.`,
+	},
 	{
 		name:         "dryRun",
 		defaultValue: false,
@@ -97,6 +125,12 @@ LaunchDarkly API is unreachable or returns an unexpected response.`,
 		defaultValue: 10, //nolint:mnd
 		usage: `Sets the number of git commits to search in history for
 whether a feature flag was removed from code. May be set to 0 to disabled this feature. Setting this option to a high value will increase search time.`,
+	},
+	{
+		name:         "minFlagKeyLength",
+		defaultValue: DefaultMinFlagKeyLength,
+		usage: `Flags with keys shorter than this length are not searched for, since short
+keys lead to many false positives. Flag keys listed in "shortFlagKeys" are always searched for.`,
 	},
 	{
 		name:         "outDir",
@@ -154,6 +188,13 @@ LaunchDarkly UI. Acceptable values: bitbucket|custom|github|gitlab.`,
 the repository, provide the path to the subdirectory containing the configuration,
 relative to the root. Code references will only run on this provided subdirectory.
 This allows a monorepo to have multiple configuration files, one per subdirectory.`,
+	},
+	{
+		name:         "unknownFlags",
+		defaultValue: "ignore",
+		usage: `How to handle flag keys passed to SDK evaluation methods that are not flags in
+LaunchDarkly. Acceptable values: ignore|warn|error. If "warn", each unknown flag key is logged
+with the nearest existing flag keys. If "error", the scan will fail when unknown flag keys are found.`,
 	},
 	{
 		name:         "updateSequenceId",
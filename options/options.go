@@ -1,6 +1,7 @@
 package options
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"net/url"
@@ -9,15 +10,20 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/iancoleman/strcase"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 
 	"github.com/launchdarkly/ld-find-code-refs/v2/internal/validation"
 )
 
 const (
 	maxProjKeyLength = 20 // Maximum project key length
+
+	// DefaultMinFlagKeyLength is the default minimum flag key length, which helps reduce the number of false positives
+	DefaultMinFlagKeyLength = 3
 )
 
 type RepoType string
@@ -38,15 +44,81 @@ const (
 	CUSTOM    RepoType = "custom"
 )
 
+// CommentPolicy determines how references on lines that only contain comments are handled
+type CommentPolicy string
+
+func (p CommentPolicy) IsValid() error {
+	switch p.Canonical() {
+	case IncludeComments, TagComments, IgnoreComments:
+		return nil
+	}
+	return fmt.Errorf(`invalid value %q for "comments": must be %s, %s, or %s`, p, IncludeComments, TagComments, IgnoreComments)
+}
+
+func (p CommentPolicy) Canonical() CommentPolicy {
+	return CommentPolicy(strings.ToLower(string(p)))
+}
+
+const (
+	IncludeComments CommentPolicy = "include"
+	TagComments     CommentPolicy = "tag"
+	IgnoreComments  CommentPolicy = "ignore"
+)
+
+// UnknownFlagPolicy determines how flag keys in SDK evaluation calls that are not flags in LaunchDarkly are handled
+type UnknownFlagPolicy string
+
+func (p UnknownFlagPolicy) IsValid() error {
+	switch p.Canonical() {
+	case IgnoreUnknownFlags, WarnUnknownFlags, ErrorUnknownFlags:
+		return nil
+	}
+	return fmt.Errorf(`invalid value %q for "unknownFlags": must be %s, %s, or %s`, p, IgnoreUnknownFlags, WarnUnknownFlags, ErrorUnknownFlags)
+}
+
+func (p UnknownFlagPolicy) Canonical() UnknownFlagPolicy {
+	return UnknownFlagPolicy(strings.ToLower(string(p)))
+}
+
+const (
+	IgnoreUnknownFlags UnknownFlagPolicy = "ignore"
+	WarnUnknownFlags   UnknownFlagPolicy = "warn"
+	ErrorUnknownFlags  UnknownFlagPolicy = "error"
+)
+
 type Project struct {
 	Key     string  `mapstructure:"key"`
 	Dir     string  `mapstructure:"dir"`
 	Aliases []Alias `mapstructure:"aliases"`
+	// Replaces the top-level delimiters for this project
+	Delimiters *Delimiters `mapstructure:"delimiters"`
+	// Appended to the top-level reference patterns for this project
+	ReferencePatterns []string `mapstructure:"referencePatterns"`
+	// Appended to the top-level key templates for this project
+	KeyTemplates []string `mapstructure:"keyTemplates"`
+	// Globs of directories and files owned by this project, relative to the repository root
+	Paths []string `mapstructure:"paths"`
+	// Replaces the top-level contextLines for this project
+	ContextLines *int `mapstructure:"contextLines"`
+	// Replaces the top-level skipArchivedFlags for this project
+	SkipArchivedFlags *bool `mapstructure:"skipArchivedFlags"`
+	// Replaces the top-level flag filter for this project
+	FlagFilter *FlagFilter `mapstructure:"flagFilter"`
+	// Replaces the top-level minFlagKeyLength for this project
+	MinFlagKeyLength *int `mapstructure:"minFlagKeyLength"`
+	// Appended to the top-level short flag keys for this project
+	ShortFlagKeys []string `mapstructure:"shortFlagKeys"`
+	// Globs of files searched for this project, relative to the repository root. If empty, all files are searched
+	Include []string `mapstructure:"include"`
+	// Globs of files not searched for this project, relative to the repository root
+	Ignore []string `mapstructure:"ignore"`
 }
 type Options struct {
-	AccessToken         string `mapstructure:"accessToken"`
+	AccessToken         string `mapstructure:"accessToken" yaml:"-"`
+	AliasCollisions     string `mapstructure:"aliasCollisions"`
 	BaseUri             string `mapstructure:"baseUri"`
 	Branch              string `mapstructure:"branch"`
+	Comments            string `mapstructure:"comments"`
 	CommitUrlTemplate   string `mapstructure:"commitUrlTemplate"`
 	DefaultBranch       string `mapstructure:"defaultBranch"`
 	Dir                 string `mapstructure:"dir" yaml:"-"`
@@ -58,12 +130,16 @@ type Options struct {
 	RepoUrl             string `mapstructure:"repoUrl"`
 	Revision            string `mapstructure:"revision"`
 	Subdirectory        string `mapstructure:"subdirectory"`
+	UnknownFlags        string `mapstructure:"unknownFlags"`
 	UserAgent           string `mapstructure:"userAgent"`
 	ContextLines        int    `mapstructure:"contextLines"`
 	Lookback            int    `mapstructure:"lookback"`
+	MinFlagKeyLength    int    `mapstructure:"minFlagKeyLength"`
 	UpdateSequenceId    int    `mapstructure:"updateSequenceId"`
 	AllowTags           bool   `mapstructure:"allowTags"`
+	CaseInsensitive     bool   `mapstructure:"caseInsensitive"`
 	Debug               bool   `mapstructure:"debug"`
+	Discover            bool   `mapstructure:"discover"`
 	DryRun              bool   `mapstructure:"dryRun"`
 	IgnoreServiceErrors bool   `mapstructure:"ignoreServiceErrors"`
 	Prune               bool   `mapstructure:"prune"`
@@ -71,15 +147,66 @@ type Options struct {
 
 	// The following options can only be configured via YAML configuration
 
-	Aliases    []Alias    `mapstructure:"aliases"`
-	Delimiters Delimiters `mapstructure:"delimiters"`
-	Projects   []Project  `mapstructure:"projects"`
+	// Configuration files merged before this file, relative to this file
+	Extends []string `mapstructure:"extends"`
+	// Configuration files whose lists are appended to the lists of this file, relative to this file
+	Include     []string     `mapstructure:"include"`
+	Aliases     []Alias      `mapstructure:"aliases"`
+	CaseOptions *CaseOptions `mapstructure:"caseOptions"`
+	Delimiters  Delimiters   `mapstructure:"delimiters"`
+	FlagFilter  FlagFilter   `mapstructure:"flagFilter"`
+	Projects    []Project    `mapstructure:"projects"`
+	// Code reference repositories for directories of the scanned repository, in addition to the top-level repository
+	Repositories []Repository `mapstructure:"repositories"`
+	// Flag keys built at runtime, where '*' matches the dynamic part of the key, e.g. `checkout-*`
+	KeyTemplates []string `mapstructure:"keyTemplates"`
+	// Regular expressions matching references, containing FLAG_KEY or a capture group named flagKey
+	ReferencePatterns []string `mapstructure:"referencePatterns"`
+	// Flag keys shorter than minFlagKeyLength that are searched for
+	ShortFlagKeys []string `mapstructure:"shortFlagKeys"`
 }
 
 type Delimiters struct {
 	// If set to `true`, the default delimiters (single-quote, double-qoute, and backtick) will not be used unless provided as `additional` delimiters
 	DisableDefaults bool     `mapstructure:"disableDefaults"`
 	Additional      []string `mapstructure:"additional"`
+	// Pairs of left and right delimiters, which are only matched together
+	Pairs []DelimiterPair `mapstructure:"pairs"`
+	// If set to `true`, flag keys matched without delimiters and aliases only count when the characters next to them are not identifier characters
+	WordBoundaries bool `mapstructure:"wordBoundaries"`
+	// Characters that are part of identifiers, as a regular expression character class. Defaults to Unicode letters and numbers, `_`, and `$`
+	IdentifierChars string `mapstructure:"identifierChars"`
+}
+
+// DelimiterPair is a left and right delimiter of any length surrounding a flag key, e.g. `${` and `}`
+type DelimiterPair struct {
+	Left  string `mapstructure:"left"`
+	Right string `mapstructure:"right"`
+}
+
+// match all non-control ASCII characters
+var validDelims = regexp.MustCompile("^[\x20-\x7E]+$")
+
+func (d Delimiters) validate(field string) error {
+	for i, delim := range d.Additional {
+		if len(delim) != 1 || !validDelims.MatchString(delim) {
+			return fmt.Errorf(`invalid value %q for "%s.additional[%d]": each delimiter must be a valid non-control ASCII character`, delim, field, i)
+		}
+	}
+	if d.IdentifierChars != "" {
+		if _, err := regexp.Compile("[" + d.IdentifierChars + "]"); err != nil {
+			return fmt.Errorf(`invalid value %q for "%s.identifierChars": must be a valid regular expression character class: %v`, d.IdentifierChars, field, err)
+		}
+	}
+	for i, pair := range d.Pairs {
+		if !validDelims.MatchString(pair.Left) {
+			return fmt.Errorf(`invalid value %q for "%s.pairs[%d].left": delimiters must be one or more non-control ASCII characters`, pair.Left, field, i)
+		}
+		if !validDelims.MatchString(pair.Right) {
+			return fmt.Errorf(`invalid value %q for "%s.pairs[%d].right": delimiters must be one or more non-control ASCII characters`, pair.Right, field, i)
+		}
+	}
+	return nil
 }
 
 func Init(flagSet *pflag.FlagSet) error {
@@ -103,7 +230,16 @@ func Init(flagSet *pflag.FlagSet) error {
 }
 
 func InitYAML() error {
-	err := validateYAMLPreconditions()
+	return initYAML(true)
+}
+
+// InitYAMLWithoutAccessToken reads the configuration file like InitYAML, without requiring an access token, to validate the configuration
+func InitYAMLWithoutAccessToken() error {
+	return initYAML(false)
+}
+
+func initYAML(requireAccessToken bool) error {
+	err := validateYAMLPreconditions(requireAccessToken)
 	if err != nil {
 		return err
 	}
@@ -117,18 +253,30 @@ func InitYAML() error {
 	configPath := filepath.Join(absPath, subdirectoryPath, ".launchdarkly")
 	viper.AddConfigPath(configPath)
 	err = viper.ReadInConfig()
-	if err != nil && !errors.As(err, &viper.ConfigFileNotFoundError{}) {
+	if err != nil {
+		if errors.As(err, &viper.ConfigFileNotFoundError{}) {
+			return nil
+		}
 		return err
 	}
-	return nil
+	rawConfig, configFiles, err = readRawConfig(viper.ConfigFileUsed())
+	if err != nil {
+		return err
+	}
+	// replace the file read by viper with the merged configuration
+	data, err := yaml.Marshal(rawConfig)
+	if err != nil {
+		return err
+	}
+	return viper.ReadConfig(bytes.NewReader(data))
 }
 
 // validatePreconditions ensures required flags have been set
-func validateYAMLPreconditions() error {
+func validateYAMLPreconditions(requireAccessToken bool) error {
 	token := viper.GetString("accessToken")
 	dir := viper.GetString("dir")
 	missingRequiredOptions := []string{}
-	if token == "" {
+	if requireAccessToken && token == "" {
 		missingRequiredOptions = append(missingRequiredOptions, "accessToken")
 	}
 	if dir == "" {
@@ -142,10 +290,25 @@ func validateYAMLPreconditions() error {
 
 func GetOptions() (Options, error) {
 	var opts Options
-	err := viper.Unmarshal(&opts)
+	if err := viper.Unmarshal(&opts); err != nil {
+		return opts, err
+	}
+	err := restoreMapKeyCase(&opts, rawConfig)
 	return opts, err
 }
 
+// GetAPIOptions returns the options of commands that only call the LaunchDarkly API: the access token, base URI and user agent,
+// and the repoName and debug options. It neither requires dir nor reads the configuration file.
+func GetAPIOptions() Options {
+	return Options{
+		AccessToken: viper.GetString("accessToken"),
+		BaseUri:     viper.GetString("baseUri"),
+		UserAgent:   viper.GetString("userAgent"),
+		RepoName:    viper.GetString("repoName"),
+		Debug:       viper.GetBool("debug"),
+	}
+}
+
 func GetWrapperOptions(dir string, merge func(Options) (Options, error)) (Options, error) {
 	flags := pflag.CommandLine
 
@@ -178,8 +341,13 @@ func GetWrapperOptions(dir string, merge func(Options) (Options, error)) (Option
 }
 
 func (o Options) ValidateRequired() error {
+	return o.validateRequired(true)
+}
+
+// validateRequired checks required options. The access token is not required to validate the configuration, as it is not part of it.
+func (o Options) validateRequired(requireAccessToken bool) error {
 	missingRequiredOptions := []string{}
-	if o.AccessToken == "" {
+	if requireAccessToken && o.AccessToken == "" {
 		missingRequiredOptions = append(missingRequiredOptions, "accessToken")
 	}
 	if o.Dir == "" {
@@ -219,7 +387,11 @@ func (o Options) ValidateRequired() error {
 
 // Validate ensures all options have been set to a valid value
 func (o Options) Validate() error {
-	if err := o.ValidateRequired(); err != nil {
+	return o.validate(true)
+}
+
+func (o Options) validate(requireAccessToken bool) error {
+	if err := o.validateRequired(requireAccessToken); err != nil {
 		return err
 	}
 
@@ -239,11 +411,65 @@ func (o Options) Validate() error {
 		}
 	}
 
-	// match all non-control ASCII characters
-	validDelims := regexp.MustCompile("^[\x20-\x7E]$")
-	for i, d := range o.Delimiters.Additional {
-		if !validDelims.MatchString(d) {
-			return fmt.Errorf(`invalid value %q for "delimiters.additional[%d]": each delimiter must be a valid non-control ASCII character`, d, i)
+	if err := o.validateRepositories(); err != nil {
+		return err
+	}
+
+	if o.MinFlagKeyLength < 0 {
+		return fmt.Errorf(`invalid value %d for "minFlagKeyLength": must be >= 0`, o.MinFlagKeyLength)
+	}
+
+	if err := o.Delimiters.validate("delimiters"); err != nil {
+		return err
+	}
+	if err := o.FlagFilter.validate("flagFilter"); err != nil {
+		return err
+	}
+	for i, pattern := range o.ReferencePatterns {
+		if err := validateReferencePattern(fmt.Sprintf("referencePatterns[%d]", i), pattern); err != nil {
+			return err
+		}
+	}
+	for i, template := range o.KeyTemplates {
+		if err := validateKeyTemplate(fmt.Sprintf("keyTemplates[%d]", i), template); err != nil {
+			return err
+		}
+	}
+	for i, project := range o.Projects {
+		if project.Delimiters != nil {
+			if err := project.Delimiters.validate(fmt.Sprintf("projects[%d].delimiters", i)); err != nil {
+				return err
+			}
+		}
+		if project.FlagFilter != nil {
+			if err := project.FlagFilter.validate(fmt.Sprintf("projects[%d].flagFilter", i)); err != nil {
+				return err
+			}
+		}
+		for j, pattern := range project.ReferencePatterns {
+			if err := validateReferencePattern(fmt.Sprintf("projects[%d].referencePatterns[%d]", i, j), pattern); err != nil {
+				return err
+			}
+		}
+		for j, template := range project.KeyTemplates {
+			if err := validateKeyTemplate(fmt.Sprintf("projects[%d].keyTemplates[%d]", i, j), template); err != nil {
+				return err
+			}
+		}
+		if project.MinFlagKeyLength != nil && *project.MinFlagKeyLength < 0 {
+			return fmt.Errorf(`invalid value %d for "projects[%d].minFlagKeyLength": must be >= 0`, *project.MinFlagKeyLength, i)
+		}
+		if project.ContextLines != nil && *project.ContextLines > maxContextLines {
+			return fmt.Errorf(`invalid value %d for "projects[%d].contextLines": must be <= %d`, *project.ContextLines, i, maxContextLines)
+		}
+		if err := validateGlobs(fmt.Sprintf("projects[%d].paths", i), project.Paths); err != nil {
+			return err
+		}
+		if err := validateGlobs(fmt.Sprintf("projects[%d].include", i), project.Include); err != nil {
+			return err
+		}
+		if err := validateGlobs(fmt.Sprintf("projects[%d].ignore", i), project.Ignore); err != nil {
+			return err
 		}
 	}
 
@@ -257,12 +483,40 @@ func (o Options) Validate() error {
 		}
 	}
 
+	if o.AliasCollisions != "" {
+		if err := AliasCollisionPolicy(o.AliasCollisions).IsValid(); err != nil {
+			return err
+		}
+	}
+
+	if o.Comments != "" {
+		if err := CommentPolicy(o.Comments).IsValid(); err != nil {
+			return err
+		}
+	}
+
+	if o.UnknownFlags != "" {
+		if err := UnknownFlagPolicy(o.UnknownFlags).IsValid(); err != nil {
+			return err
+		}
+	}
+
+	if o.CaseOptions != nil {
+		if err := o.CaseOptions.IsValid(); err != nil {
+			return err
+		}
+	}
+
 	for _, a := range o.Aliases {
 		if err := a.IsValid(); err != nil {
 			return err
 		}
 	}
 
+	if o.Discover && o.Subdirectory != "" {
+		return errors.New(`"discover" option cannot be combined with "subdirectory"`)
+	}
+
 	if o.Revision != "" && o.Branch == "" {
 		return errors.New(`"branch" option is required when "revision" option is set`)
 	}
@@ -281,6 +535,15 @@ func (o Options) Validate() error {
 	return nil
 }
 
+func validateGlobs(field string, globs []string) error {
+	for i, glob := range globs {
+		if !doublestar.ValidatePattern(glob) {
+			return fmt.Errorf(`invalid value %q for "%s[%d]": must be a valid glob`, glob, field, i)
+		}
+	}
+	return nil
+}
+
 func projKeyValidation(projKey string) error {
 	if strings.HasPrefix(projKey, "sdk-") {
 		return fmt.Errorf("provided project key (%s) appears to be a LaunchDarkly SDK key", "sdk-xxxx")
@@ -291,6 +554,73 @@ func projKeyValidation(projKey string) error {
 	return nil
 }
 
+// ProjectAliases returns the global aliases followed by the aliases of the project. Naming convention aliases without
+// their own case options use the top-level case options.
+func (o Options) ProjectAliases(project Project) []Alias {
+	aliases := make([]Alias, 0, len(o.Aliases)+len(project.Aliases))
+	aliases = append(aliases, o.Aliases...)
+	aliases = append(aliases, project.Aliases...)
+	for i, a := range aliases {
+		if a.CaseOptions == nil && a.Type.IsNamingConvention() {
+			aliases[i].CaseOptions = o.CaseOptions
+		}
+	}
+	return aliases
+}
+
+// ProjectDelimiters returns the delimiters configured for the project, or the top-level delimiters
+func (o Options) ProjectDelimiters(project Project) Delimiters {
+	if project.Delimiters != nil {
+		return *project.Delimiters
+	}
+	return o.Delimiters
+}
+
+// ProjectReferencePatterns returns the top-level reference patterns followed by the reference patterns of the project
+func (o Options) ProjectReferencePatterns(project Project) []string {
+	patterns := make([]string, 0, len(o.ReferencePatterns)+len(project.ReferencePatterns))
+	patterns = append(patterns, o.ReferencePatterns...)
+	return append(patterns, project.ReferencePatterns...)
+}
+
+// ProjectContextLines returns the number of context lines configured for the project, or the top-level number of context lines
+func (o Options) ProjectContextLines(project Project) int {
+	if project.ContextLines != nil {
+		return *project.ContextLines
+	}
+	return o.ContextLines
+}
+
+// ProjectSkipArchivedFlags returns whether archived flags are skipped for the project, defaulting to the top-level option
+func (o Options) ProjectSkipArchivedFlags(project Project) bool {
+	if project.SkipArchivedFlags != nil {
+		return *project.SkipArchivedFlags
+	}
+	return o.SkipArchivedFlags
+}
+
+// ProjectMinFlagKeyLength returns the minimum flag key length configured for the project, or the top-level minimum flag key length
+func (o Options) ProjectMinFlagKeyLength(project Project) int {
+	if project.MinFlagKeyLength != nil {
+		return *project.MinFlagKeyLength
+	}
+	return o.MinFlagKeyLength
+}
+
+// ProjectShortFlagKeys returns the top-level short flag keys followed by the short flag keys of the project
+func (o Options) ProjectShortFlagKeys(project Project) []string {
+	keys := make([]string, 0, len(o.ShortFlagKeys)+len(project.ShortFlagKeys))
+	keys = append(keys, o.ShortFlagKeys...)
+	return append(keys, project.ShortFlagKeys...)
+}
+
+// ProjectKeyTemplates returns the top-level key templates followed by the key templates of the project
+func (o Options) ProjectKeyTemplates(project Project) []string {
+	templates := make([]string, 0, len(o.KeyTemplates)+len(project.KeyTemplates))
+	templates = append(templates, o.KeyTemplates...)
+	return append(templates, project.KeyTemplates...)
+}
+
 func (o Options) GetProjectKeys() (projects []string) {
 	for _, project := range o.Projects {
 		projects = append(projects, project.Key)
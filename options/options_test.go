@@ -0,0 +1,482 @@
+package options
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, contents string) string {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".launchdarkly"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".launchdarkly", "coderefs.yaml"), []byte(contents), 0600))
+	return dir
+}
+
+func loadOptions(t *testing.T, dir string) Options {
+	viper.Reset()
+	rawConfig, configFiles = nil, nil
+	t.Cleanup(viper.Reset)
+	viper.Set("dir", dir)
+	viper.Set("accessToken", "api-x")
+	require.NoError(t, InitYAML())
+	opts, err := GetOptions()
+	require.NoError(t, err)
+	return opts
+}
+
+func TestGetOptions_preservesMapKeyCase(t *testing.T) {
+	dir := writeConfig(t, `
+repoName: Test-Repo
+aliases:
+  - type: literal
+    flags:
+      My-Flag:
+        - myFlag
+projects:
+  - key: proj
+    aliases:
+      - type: literal
+        flags:
+          enableAPI:
+            - ENABLE_API
+`)
+	opts := loadOptions(t, dir)
+
+	assert.Equal(t, "Test-Repo", opts.RepoName)
+	require.Len(t, opts.Aliases, 1)
+	assert.Equal(t, map[string][]string{"My-Flag": {"myFlag"}}, opts.Aliases[0].Flags)
+	require.Len(t, opts.Projects, 1)
+	require.Len(t, opts.Projects[0].Aliases, 1)
+	assert.Equal(t, map[string][]string{"enableAPI": {"ENABLE_API"}}, opts.Projects[0].Aliases[0].Flags)
+}
+
+func TestGetOptions_withoutConfigFile(t *testing.T) {
+	opts := loadOptions(t, t.TempDir())
+	assert.Empty(t, opts.Aliases)
+}
+
+func TestOptions_ProjectAliases(t *testing.T) {
+	caseOptions := &CaseOptions{Acronyms: []string{"API"}}
+	aliasCaseOptions := &CaseOptions{Numbers: SplitNumbers}
+	opts := Options{
+		CaseOptions: caseOptions,
+		Aliases:     []Alias{{Type: CamelCase}, {Type: Literal, Flags: map[string][]string{"flag": {"FLAG"}}}},
+	}
+	project := Project{Key: "proj", Aliases: []Alias{{Type: SnakeCase, CaseOptions: aliasCaseOptions}}}
+
+	aliases := opts.ProjectAliases(project)
+
+	require.Len(t, aliases, 3)
+	assert.Equal(t, caseOptions, aliases[0].CaseOptions)
+	assert.Nil(t, aliases[1].CaseOptions)
+	assert.Equal(t, aliasCaseOptions, aliases[2].CaseOptions)
+	assert.Nil(t, opts.Aliases[0].CaseOptions, "global aliases should not be modified")
+}
+
+func TestGetOptions_caseOptions(t *testing.T) {
+	dir := writeConfig(t, `
+caseOptions:
+  acronyms: [API, ID]
+  numbers: split
+  variants: true
+`)
+	opts := loadOptions(t, dir)
+
+	assert.Equal(t, &CaseOptions{Acronyms: []string{"API", "ID"}, Numbers: SplitNumbers, Variants: true}, opts.CaseOptions)
+	assert.NoError(t, opts.CaseOptions.IsValid())
+	assert.Error(t, (&CaseOptions{Numbers: "join"}).IsValid())
+	assert.Error(t, (&CaseOptions{Acronyms: []string{"A-B"}}).IsValid())
+}
+
+func TestDelimiters_validate(t *testing.T) {
+	valid := Delimiters{Additional: []string{"<"}, Pairs: []DelimiterPair{{Left: "{{ flag \"", Right: "\" }}"}}}
+	assert.NoError(t, valid.validate("delimiters"))
+
+	err := Delimiters{Additional: []string{"<<"}}.validate("delimiters")
+	assert.EqualError(t, err, `invalid value "<<" for "delimiters.additional[0]": each delimiter must be a valid non-control ASCII character`)
+
+	err = Delimiters{Pairs: []DelimiterPair{{Left: "${"}}}.validate("projects[1].delimiters")
+	assert.EqualError(t, err, `invalid value "" for "projects[1].delimiters.pairs[0].right": delimiters must be one or more non-control ASCII characters`)
+}
+
+func TestGetOptions_projectDelimiters(t *testing.T) {
+	dir := writeConfig(t, `
+delimiters:
+  additional: ["<"]
+projects:
+  - key: default
+  - key: templates
+    delimiters:
+      disableDefaults: true
+      pairs:
+        - left: "${"
+          right: "}"
+`)
+	opts := loadOptions(t, dir)
+
+	require.Len(t, opts.Projects, 2)
+	assert.Equal(t, Delimiters{Additional: []string{"<"}}, opts.ProjectDelimiters(opts.Projects[0]))
+	assert.Equal(t, Delimiters{DisableDefaults: true, Pairs: []DelimiterPair{{Left: "${", Right: "}"}}}, opts.ProjectDelimiters(opts.Projects[1]))
+}
+
+func TestGetOptions_projectSettings(t *testing.T) {
+	dir := writeConfig(t, `
+contextLines: 1
+skipArchivedFlags: true
+projects:
+  - key: default
+  - key: terraform
+    contextLines: 0
+    skipArchivedFlags: false
+    paths: ["infra", "modules/*"]
+    include: ["infra/**/*.tf"]
+    ignore: ["infra/modules/**"]
+`)
+	opts := loadOptions(t, dir)
+
+	require.Len(t, opts.Projects, 2)
+	assert.Equal(t, 1, opts.ProjectContextLines(opts.Projects[0]))
+	assert.Equal(t, 0, opts.ProjectContextLines(opts.Projects[1]))
+	assert.True(t, opts.ProjectSkipArchivedFlags(opts.Projects[0]))
+	assert.False(t, opts.ProjectSkipArchivedFlags(opts.Projects[1]))
+	assert.Equal(t, []string{"infra", "modules/*"}, opts.Projects[1].Paths)
+	assert.Equal(t, []string{"infra/**/*.tf"}, opts.Projects[1].Include)
+	assert.Equal(t, []string{"infra/modules/**"}, opts.Projects[1].Ignore)
+}
+
+func Test_validateGlobs(t *testing.T) {
+	assert.NoError(t, validateGlobs("projects[0].include", []string{"**/*.tf"}))
+	assert.EqualError(t, validateGlobs("projects[0].ignore", []string{"*.go", "[a-"}), `invalid value "[a-" for "projects[0].ignore[1]": must be a valid glob`)
+}
+
+func Test_validateReferencePattern(t *testing.T) {
+	assert.NoError(t, validateReferencePattern("referencePatterns[0]", `variation\(\w+, "FLAG_KEY"`))
+	assert.NoError(t, validateReferencePattern("referencePatterns[0]", `^(?P<flagKey>[\w-]+):`))
+	// compiled with RE2, which matches any byte with \C unlike Go's regexp
+	assert.NoError(t, validateReferencePattern("referencePatterns[0]", `\C"FLAG_KEY"`))
+	assert.EqualError(t, validateReferencePattern("referencePatterns[1]", `LDFlag\(`), `invalid value "LDFlag\\(" for "referencePatterns[1]": must contain FLAG_KEY or a capture group named 'flagKey'`)
+	assert.Error(t, validateReferencePattern("referencePatterns[0]", `FLAG_KEY FLAG_KEY`))
+	assert.Error(t, validateReferencePattern("referencePatterns[0]", `(FLAG_KEY`))
+}
+
+func TestCommentPolicy_IsValid(t *testing.T) {
+	assert.NoError(t, CommentPolicy("Tag").IsValid())
+	assert.EqualError(t, CommentPolicy("skip").IsValid(), `invalid value "skip" for "comments": must be include, tag, or ignore`)
+}
+
+func Test_validateKeyTemplate(t *testing.T) {
+	assert.NoError(t, validateKeyTemplate("keyTemplates[0]", "checkout-*"))
+	assert.NoError(t, validateKeyTemplate("keyTemplates[0]", "*-beta"))
+	assert.EqualError(t, validateKeyTemplate("keyTemplates[0]", "checkout"), `invalid value "checkout" for "keyTemplates[0]": must contain '*'`)
+	assert.EqualError(t, validateKeyTemplate("keyTemplates[0]", "**"), `invalid value "**" for "keyTemplates[0]": must contain a static prefix or suffix`)
+	assert.Error(t, validateKeyTemplate("keyTemplates[0]", "checkout-${name}"))
+}
+
+func TestUnknownFlagPolicy_IsValid(t *testing.T) {
+	assert.NoError(t, UnknownFlagPolicy("Error").IsValid())
+	assert.EqualError(t, UnknownFlagPolicy("fail").IsValid(), `invalid value "fail" for "unknownFlags": must be ignore, warn, or error`)
+}
+
+func TestOptions_ForSubdirectory(t *testing.T) {
+	dir := writeConfig(t, `
+repoName: root
+contextLines: 1
+projects:
+  - key: default
+aliases:
+  - type: camelcase
+`)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "services", "api", ".launchdarkly"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "services", "api", ".launchdarkly", "coderefs.yaml"), []byte(`
+repoName: api
+projKey: api-project
+aliases:
+  - type: literal
+    flags:
+      New-Checkout: [NEW_CHECKOUT]
+`), 0600))
+	opts := loadOptions(t, dir)
+
+	got, err := opts.ForSubdirectory("services/api")
+	require.NoError(t, err)
+	assert.Equal(t, "api", got.RepoName)
+	assert.Equal(t, "services/api", got.Subdirectory)
+	assert.Equal(t, "api-project", got.ProjKey)
+	assert.Empty(t, got.Projects)
+	assert.Equal(t, 1, got.ContextLines)
+	assert.Equal(t, dir, got.Dir)
+	require.Len(t, got.Aliases, 1)
+	assert.Equal(t, map[string][]string{"New-Checkout": {"NEW_CHECKOUT"}}, got.Aliases[0].Flags)
+	// the root options are unchanged
+	assert.Equal(t, "root", opts.RepoName)
+	require.Len(t, opts.Projects, 1)
+
+	_, err = opts.ForSubdirectory("missing")
+	assert.Error(t, err)
+}
+
+func TestOptions_validateRepositories(t *testing.T) {
+	opts := Options{RepoName: "monorepo", Repositories: []Repository{
+		{Name: "api", Paths: []string{"services/api"}, Type: "github"},
+		{Name: "web", Paths: []string{"web/**"}},
+	}}
+	assert.NoError(t, opts.validateRepositories())
+
+	opts.Repositories[1].Name = "monorepo"
+	assert.EqualError(t, opts.validateRepositories(), `invalid value "monorepo" for "repositories[1].name": each repository must have a different name than "repoName" and other repositories`)
+	opts.Repositories[1] = Repository{Name: "web"}
+	assert.EqualError(t, opts.validateRepositories(), `missing value for "repositories[1].paths"`)
+	opts.Repositories[1] = Repository{Name: "web", Paths: []string{"web"}, Type: "svn"}
+	assert.EqualError(t, opts.validateRepositories(), `invalid value "svn" for "repositories[1].type": must be github, gitlab, bitbucket, or custom`)
+}
+
+func TestOptions_WithRepository(t *testing.T) {
+	opts := Options{RepoName: "monorepo", RepoType: "github", RepoUrl: "https://github.com/org/monorepo", DefaultBranch: "main", Repositories: []Repository{{Name: "api"}}}
+	got := opts.WithRepository(Repository{Name: "api", HunkUrlTemplate: "https://github.com/org/monorepo/blob/${sha}/${filePath}#L${lineNumber}"})
+
+	assert.Equal(t, "api", got.RepoName)
+	assert.Equal(t, "github", got.RepoType)
+	assert.Equal(t, "https://github.com/org/monorepo", got.RepoUrl)
+	assert.Equal(t, "https://github.com/org/monorepo/blob/${sha}/${filePath}#L${lineNumber}", got.HunkUrlTemplate)
+	assert.Empty(t, got.Repositories)
+}
+
+func TestSchema_upToDate(t *testing.T) {
+	published, err := os.ReadFile(filepath.Join("..", "docs", "coderefs.schema.json"))
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, WriteSchema(&buf))
+	assert.Equal(t, string(published), buf.String(), "run `make schema` to update docs/coderefs.schema.json")
+}
+
+func validateConfig(t *testing.T, contents string) []string {
+	dir := writeConfig(t, contents)
+	viper.Reset()
+	rawConfig, configFiles = nil, nil
+	t.Cleanup(viper.Reset)
+	viper.Set("dir", dir)
+	viper.Set("repoName", "repo")
+	viper.Set("repoType", "custom")
+	require.NoError(t, InitYAMLWithoutAccessToken())
+	configErrs := ValidateConfig()
+	path := filepath.Join(dir, ".launchdarkly", "coderefs.yaml")
+	messages := []string{}
+	for _, configErr := range configErrs {
+		assert.Equal(t, path, configErr.Path)
+		messages = append(messages, fmt.Sprintf("%d: %s", configErr.Line, configErr.Message))
+	}
+	return messages
+}
+
+func TestValidateConfig(t *testing.T) {
+	assert.Empty(t, validateConfig(t, `
+projKey: proj
+contextLines: 2
+aliases:
+  - type: camelcase
+`))
+
+	assert.Equal(t, []string{
+		`2: unknown field "contextLine", did you mean "contextLines"?`,
+		`5: unknown field "aliases[0].pattern", did you mean "patterns"?`,
+		`7: invalid value for "delimiters.additional": expected a list`,
+		`8: invalid value for "skipArchivedFlags": expected true or false`,
+		`9: "accessToken" may only be set with a command line flag or environment variable`,
+	}, validateConfig(t, `
+contextLine: 2
+aliases:
+  - type: filepattern
+    pattern: FLAG_KEY
+delimiters:
+  additional: {a: b}
+skipArchivedFlags: sometimes
+accessToken: api-x
+`))
+
+	assert.Equal(t, []string{
+		`6: invalid alias "projects[0].aliases[1]": filepattern aliases must provide at least one path in 'paths'`,
+	}, validateConfig(t, `
+projects:
+  - key: proj
+    aliases:
+      - type: camelcase
+      - type: filepattern
+`))
+
+	assert.Equal(t, []string{
+		`5: invalid value "<<" for "delimiters.additional[0]": each delimiter must be a valid non-control ASCII character`,
+	}, validateConfig(t, `
+projKey: proj
+delimiters:
+  additional:
+    - "<<"
+`))
+}
+
+func TestGetAPIOptions(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("accessToken", "api-x")
+	viper.Set("repoName", "web")
+	viper.Set("contextLines", 3)
+
+	assert.Equal(t, Options{AccessToken: "api-x", RepoName: "web"}, GetAPIOptions())
+}
+
+func TestGetOptions_extends(t *testing.T) {
+	dir := writeConfig(t, `
+extends:
+  - ../shared/base.yaml
+repoName: ${REPO_NAME}
+contextLines: ${CONTEXT_LINES:-2}
+hunkUrlTemplate: https://example.com/${sha}/${filePath}#L${lineNumber}
+delimiters:
+  additional: ["<"]
+`)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "shared"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shared", "base.yaml"), []byte(`
+extends: common.yaml
+repoName: base
+delimiters:
+  disableDefaults: true
+  additional: [">"]
+aliases:
+  - type: literal
+    flags:
+      New-Checkout: ["$${NEW_CHECKOUT}"]
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shared", "common.yaml"), []byte(`
+projKey: common
+`), 0600))
+	t.Setenv("REPO_NAME", "from-env")
+
+	opts := loadOptions(t, dir)
+
+	assert.Equal(t, "from-env", opts.RepoName)
+	assert.Equal(t, "common", opts.ProjKey)
+	assert.Equal(t, 2, opts.ContextLines)
+	assert.Equal(t, "https://example.com/${sha}/${filePath}#L${lineNumber}", opts.HunkUrlTemplate)
+	assert.Equal(t, Delimiters{DisableDefaults: true, Additional: []string{"<"}}, opts.Delimiters)
+	require.Len(t, opts.Aliases, 1)
+	assert.Equal(t, map[string][]string{"New-Checkout": {"${NEW_CHECKOUT}"}}, opts.Aliases[0].Flags)
+	assert.Equal(t, []string{
+		filepath.Join(dir, ".launchdarkly", "coderefs.yaml"),
+		filepath.Join(dir, "shared", "base.yaml"),
+		filepath.Join(dir, "shared", "common.yaml"),
+	}, ConfigFiles())
+}
+
+func TestGetOptions_include(t *testing.T) {
+	dir := writeConfig(t, `
+include: ../shared/aliases.yaml
+extends: ../shared/base.yaml
+repoName: my-repo
+contextlines: 3
+referencePatterns: ["flag\\((FLAG_KEY)\\)"]
+delimiters:
+  additional: ["<"]
+aliases:
+  - type: camelcase
+`)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "shared"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shared", "base.yaml"), []byte(`
+projKey: base
+contextLines: 1
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shared", "aliases.yaml"), []byte(`
+repoName: shared
+contextLines: 5
+referencePatterns: ["variation\\((FLAG_KEY)\\)"]
+delimiters:
+  disableDefaults: true
+  additional: [">"]
+aliases:
+  - type: snakecase
+`), 0600))
+
+	opts := loadOptions(t, dir)
+
+	assert.Equal(t, "my-repo", opts.RepoName)
+	assert.Equal(t, "base", opts.ProjKey)
+	assert.Equal(t, 3, opts.ContextLines)
+	assert.Equal(t, []string{`flag\((FLAG_KEY)\)`, `variation\((FLAG_KEY)\)`}, opts.ReferencePatterns)
+	assert.Equal(t, Delimiters{DisableDefaults: true, Additional: []string{"<", ">"}}, opts.Delimiters)
+	require.Len(t, opts.Aliases, 2)
+	assert.Equal(t, CamelCase, opts.Aliases[0].Type.Canonical())
+	assert.Equal(t, SnakeCase, opts.Aliases[1].Type.Canonical())
+	assert.Equal(t, []string{
+		filepath.Join(dir, ".launchdarkly", "coderefs.yaml"),
+		filepath.Join(dir, "shared", "base.yaml"),
+		filepath.Join(dir, "shared", "aliases.yaml"),
+	}, ConfigFiles())
+}
+
+func TestInitYAML_extendsErrors(t *testing.T) {
+	initYAML := func(dir string) error {
+		viper.Reset()
+		t.Cleanup(viper.Reset)
+		viper.Set("dir", dir)
+		viper.Set("accessToken", "api-x")
+		return InitYAML()
+	}
+
+	dir := writeConfig(t, "repoName: ${UNSET_REPO_NAME}\n")
+	assert.EqualError(t, initYAML(dir), filepath.Join(dir, ".launchdarkly", "coderefs.yaml")+`:1: environment variable "UNSET_REPO_NAME" is not set`)
+
+	dir = writeConfig(t, "extends: other.yaml\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".launchdarkly", "other.yaml"), []byte("extends: coderefs.yaml\n"), 0600))
+	err := initYAML(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "configuration files extend or include each other")
+}
+
+func TestGetOptions_flagFilter(t *testing.T) {
+	dir := writeConfig(t, `
+flagFilter:
+  include: ["^platform-"]
+  type: temporary
+projects:
+  - key: default
+  - key: payments
+    flagFilter:
+      tags: [payments]
+      maintainerTeams: [payments-team]
+`)
+	opts := loadOptions(t, dir)
+
+	require.Len(t, opts.Projects, 2)
+	assert.Equal(t, FlagFilter{Include: []string{"^platform-"}, Type: TemporaryFlags}, opts.ProjectFlagFilter(opts.Projects[0]))
+	assert.Equal(t, FlagFilter{Tags: []string{"payments"}, MaintainerTeams: []string{"payments-team"}}, opts.ProjectFlagFilter(opts.Projects[1]))
+}
+
+func TestFlagFilter_validate(t *testing.T) {
+	assert.NoError(t, FlagFilter{Type: "Permanent", Exclude: []string{"-test$"}}.validate("flagFilter"))
+	assert.EqualError(t, FlagFilter{Type: "experiment"}.validate("flagFilter"), `invalid value "experiment" for "flagFilter.type": must be temporary or permanent`)
+	assert.Error(t, FlagFilter{Include: []string{"(platform"}}.validate("projects[0].flagFilter"))
+}
+
+func TestGetOptions_shortFlagKeys(t *testing.T) {
+	dir := writeConfig(t, `
+minFlagKeyLength: 4
+shortFlagKeys: [ai]
+projects:
+  - key: default
+  - key: mobile
+    minFlagKeyLength: 6
+    shortFlagKeys: [ios]
+`)
+	opts := loadOptions(t, dir)
+
+	require.Len(t, opts.Projects, 2)
+	assert.Equal(t, 4, opts.ProjectMinFlagKeyLength(opts.Projects[0]))
+	assert.Equal(t, []string{"ai"}, opts.ProjectShortFlagKeys(opts.Projects[0]))
+	assert.Equal(t, 6, opts.ProjectMinFlagKeyLength(opts.Projects[1]))
+	assert.Equal(t, []string{"ai", "ios"}, opts.ProjectShortFlagKeys(opts.Projects[1]))
+}
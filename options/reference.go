@@ -0,0 +1,37 @@
+package options
+
+import (
+	"fmt"
+	"strings"
+
+	regexp "github.com/wasilibs/go-re2"
+)
+
+const (
+	// FlagKeyGroup is the name of the capture group containing the flag key in a reference pattern
+	FlagKeyGroup = "flagKey"
+	// flagKeyPlaceholder is replaced with a capture group matching any flag key in reference patterns
+	flagKeyPlaceholder = "FLAG_KEY"
+	// FlagKeyChars is a regular expression character class, without brackets, of all characters allowed in flag keys
+	FlagKeyChars = `A-Za-z0-9._\-`
+)
+
+// ExpandReferencePattern replaces the FLAG_KEY placeholder in a reference pattern with a named capture group matching any flag key
+func ExpandReferencePattern(pattern string) string {
+	return strings.ReplaceAll(pattern, flagKeyPlaceholder, "(?P<"+FlagKeyGroup+">["+FlagKeyChars+"]+)")
+}
+
+// validateReferencePattern compiles a reference pattern with RE2, like the search does
+func validateReferencePattern(field, pattern string) error {
+	if strings.Count(pattern, flagKeyPlaceholder) > 1 {
+		return fmt.Errorf(`invalid value %q for "%s": must contain %s at most once`, pattern, field, flagKeyPlaceholder)
+	}
+	re, err := regexp.Compile(ExpandReferencePattern(pattern))
+	if err != nil {
+		return fmt.Errorf(`invalid value %q for "%s": %v`, pattern, field, err)
+	}
+	if re.SubexpIndex(FlagKeyGroup) < 0 {
+		return fmt.Errorf(`invalid value %q for "%s": must contain %s or a capture group named '%s'`, pattern, field, flagKeyPlaceholder, FlagKeyGroup)
+	}
+	return nil
+}
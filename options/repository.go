@@ -0,0 +1,80 @@
+package options
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Repository is a LaunchDarkly code reference repository containing the references in some directories of the scanned repository.
+// Options that are not set are inherited from the top-level repository options.
+type Repository struct {
+	// Globs of directories and files in the repository, relative to the repository root
+	Paths             []string `mapstructure:"paths"`
+	Name              string   `mapstructure:"name"`
+	Type              string   `mapstructure:"type"`
+	Url               string   `mapstructure:"url"`
+	CommitUrlTemplate string   `mapstructure:"commitUrlTemplate"`
+	HunkUrlTemplate   string   `mapstructure:"hunkUrlTemplate"`
+	DefaultBranch     string   `mapstructure:"defaultBranch"`
+}
+
+func (r Repository) validate(field string) error {
+	if r.Name == "" {
+		return fmt.Errorf(`missing value for "%s.name"`, field)
+	}
+	if len(r.Paths) == 0 {
+		return fmt.Errorf(`missing value for "%s.paths"`, field)
+	}
+	if err := validateGlobs(field+".paths", r.Paths); err != nil {
+		return err
+	}
+	if r.Type != "" {
+		if err := RepoType(strings.ToLower(r.Type)).isValid(); err != nil {
+			return fmt.Errorf(`invalid value %q for "%s.type": must be %s, %s, %s, or %s`, r.Type, field, GITHUB, GITLAB, BITBUCKET, CUSTOM)
+		}
+	}
+	if r.Url != "" {
+		if _, err := url.ParseRequestURI(r.Url); err != nil {
+			return fmt.Errorf(`invalid value %q for "%s.url": %+v`, r.Url, field, err)
+		}
+	}
+	return nil
+}
+
+func (o Options) validateRepositories() error {
+	names := map[string]bool{o.RepoName: true}
+	for i, r := range o.Repositories {
+		field := fmt.Sprintf("repositories[%d]", i)
+		if err := r.validate(field); err != nil {
+			return err
+		}
+		if names[r.Name] {
+			return fmt.Errorf(`invalid value %q for "%s.name": each repository must have a different name than "repoName" and other repositories`, r.Name, field)
+		}
+		names[r.Name] = true
+	}
+	return nil
+}
+
+// WithRepository returns the options with the repository options replacing the top-level repository options
+func (o Options) WithRepository(r Repository) Options {
+	o.RepoName = r.Name
+	if r.Type != "" {
+		o.RepoType = r.Type
+	}
+	if r.Url != "" {
+		o.RepoUrl = r.Url
+	}
+	if r.CommitUrlTemplate != "" {
+		o.CommitUrlTemplate = r.CommitUrlTemplate
+	}
+	if r.HunkUrlTemplate != "" {
+		o.HunkUrlTemplate = r.HunkUrlTemplate
+	}
+	if r.DefaultBranch != "" {
+		o.DefaultBranch = r.DefaultBranch
+	}
+	o.Repositories = nil
+	return o
+}
@@ -0,0 +1,176 @@
+package options
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+)
+
+const schemaId = "https://raw.githubusercontent.com/launchdarkly/ld-find-code-refs/main/docs/coderefs.schema.json"
+
+// Descriptions of YAML-only options, keyed by type and field name. Options available as command line flags use the flag usage.
+var schemaDescriptions = map[string]string{
+	"Options.aliases":            "Patterns to match aliases of flag keys. See docs/ALIASES.md",
+	"Options.caseOptions":        "Acronyms, number handling, and spelling variants of naming convention aliases",
+	"Options.extends":            "Configuration files merged before this file, relative to this file. Options in this file replace the options of the files it extends",
+	"Options.include":            "Configuration files merged into this file, relative to this file. Their lists, such as aliases, are appended to the lists of this file, and their other options are only used if not set",
+	"Options.flagFilter":         "Limits the flags searched for to the flags matching every condition",
+	"Options.delimiters":         "Characters surrounding flag keys",
+	"Options.projects":           "LaunchDarkly projects to search for in the repository. Cannot be combined with projKey",
+	"Options.repositories":       "Code reference repositories for directories of the scanned repository, in addition to the top-level repository",
+	"Options.keyTemplates":       "Flag keys built at runtime, where '*' matches the dynamic part of the key, e.g. checkout-*",
+	"Options.referencePatterns":  "Regular expressions matching references, containing FLAG_KEY or a capture group named flagKey",
+	"Options.shortFlagKeys":      "Flag keys shorter than minFlagKeyLength that are searched for",
+	"Alias.scope":                "Limits where aliases are searched for: file, package, or a glob relative to the repository root",
+	"Alias.timeout":              "Timeout of the command in seconds",
+	"Delimiters.disableDefaults": "If true, single quotes, double quotes, and backticks are not used as delimiters unless provided as additional delimiters",
+	"Delimiters.identifierChars": "Characters that are part of identifiers, as a regular expression character class",
+	"Delimiters.wordBoundaries":  "If true, flag keys matched without delimiters and aliases only count when they are not part of a longer identifier",
+	"FlagFilter.tags":            "Only flags with at least one of these tags",
+	"FlagFilter.include":         "Regular expressions. If set, only flags with a key matching at least one of them are searched for",
+	"FlagFilter.exclude":         "Regular expressions. Flags with a key matching any of them are not searched for",
+	"FlagFilter.maintainerTeams": "Only flags maintained by one of these teams",
+	"Project.dir":                "Only search for this project in this directory",
+	"Project.paths":              "Globs of directories and files owned by this project, relative to the repository root",
+	"Project.include":            "Globs of files searched for this project, relative to the repository root",
+	"Project.ignore":             "Globs of files not searched for this project, relative to the repository root",
+	"Project.minFlagKeyLength":   "Replaces the top-level minFlagKeyLength for this project",
+	"Project.shortFlagKeys":      "Flag keys shorter than minFlagKeyLength that are searched for in this project, in addition to the top-level shortFlagKeys",
+	"Repository.paths":           "Globs of directories and files in the repository, relative to the repository root",
+}
+
+// Allowed values of options, keyed by type and field name
+var schemaEnums = map[string][]string{
+	"Options.aliasCollisions": {string(AllowCollisions), string(WarnCollisions), string(DropCollisions), string(ErrorCollisions)},
+	"Options.comments":        {string(IncludeComments), string(TagComments), string(IgnoreComments)},
+	"Options.repoType":        {string(GITHUB), string(GITLAB), string(BITBUCKET), string(CUSTOM)},
+	"Options.unknownFlags":    {string(IgnoreUnknownFlags), string(WarnUnknownFlags), string(ErrorUnknownFlags)},
+	"Alias.type": {
+		string(Literal), string(CamelCase), string(PascalCase), string(SnakeCase), string(UpperSnakeCase), string(KebabCase), string(DotCase),
+		string(FilePattern), string(Command), string(Auto),
+	},
+	"CaseOptions.numbers": {string(AttachNumbers), string(SplitNumbers)},
+	"FlagFilter.type":     {string(TemporaryFlags), string(PermanentFlags)},
+	"Repository.type":     {string(GITHUB), string(GITLAB), string(BITBUCKET), string(CUSTOM)},
+}
+
+// Options required in each configuration block, keyed by type
+var schemaRequired = map[string][]string{
+	"Alias":         {"type"},
+	"DelimiterPair": {"left", "right"},
+	"Project":       {"key"},
+	"Repository":    {"name", "paths"},
+}
+
+// Schema returns a JSON Schema of the .launchdarkly/coderefs.yaml configuration file
+func Schema() map[string]interface{} {
+	definitions := map[string]interface{}{}
+	schema := structSchema(reflect.TypeOf(Options{}), definitions)
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["$id"] = schemaId
+	schema["title"] = "ld-find-code-refs configuration"
+	schema["definitions"] = definitions
+	return schema
+}
+
+// WriteSchema writes the JSON Schema of the configuration file
+func WriteSchema(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(Schema())
+}
+
+func structSchema(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for _, field := range configFields(t) {
+		if field.commandLineOnly {
+			continue
+		}
+		property := typeSchema(field.Type, definitions)
+		key := t.Name() + "." + field.name
+		if description, ok := schemaDescriptions[key]; ok {
+			property["description"] = description
+		} else if f, ok := flagFor(t, field.name); ok && f.usage != "" {
+			property["description"] = strings.ReplaceAll(f.usage, "\n", " ")
+		}
+		if enum, ok := schemaEnums[key]; ok {
+			property["enum"] = enum
+		}
+		properties[field.name] = property
+	}
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if required, ok := schemaRequired[t.Name()]; ok {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func typeSchema(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), definitions)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), definitions)}
+	case reflect.Struct:
+		if _, ok := definitions[t.Name()]; !ok {
+			// reserve the name before generating the definition, so recursive types terminate
+			definitions[t.Name()] = nil
+			definitions[t.Name()] = structSchema(t, definitions)
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + t.Name()}
+	}
+	return map[string]interface{}{}
+}
+
+type configField struct {
+	reflect.StructField
+	// Name of the option in the configuration file
+	name string
+	// Whether the option may only be set with a command line flag or environment variable
+	commandLineOnly bool
+}
+
+// configFields returns the fields of a configuration type that are options
+func configFields(t reflect.Type) []configField {
+	fields := make([]configField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+		if name == "" {
+			continue
+		}
+		// use the casing of the command line flag, since viper ignores the casing of options
+		if f, ok := flagFor(t, name); ok {
+			name = f.name
+		}
+		fields = append(fields, configField{StructField: field, name: name, commandLineOnly: field.Tag.Get("yaml") == "-"})
+	}
+	return fields
+}
+
+func flagFor(t reflect.Type, name string) (flag, bool) {
+	if t != reflect.TypeOf(Options{}) {
+		return flag{}, false
+	}
+	for _, f := range flags {
+		if strings.EqualFold(f.name, name) {
+			return f, true
+		}
+	}
+	return flag{}, false
+}
@@ -0,0 +1,25 @@
+package options
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// KeyTemplateWildcard matches any part of a flag key in a key template
+const KeyTemplateWildcard = "*"
+
+var validKeyTemplate = regexp.MustCompile(`^[A-Za-z0-9._\-*]+$`)
+
+func validateKeyTemplate(field, template string) error {
+	if !validKeyTemplate.MatchString(template) {
+		return fmt.Errorf(`invalid value %q for "%s": may only contain letters, numbers, '.', '_', '-', and '%s'`, template, field, KeyTemplateWildcard)
+	}
+	if !strings.Contains(template, KeyTemplateWildcard) {
+		return fmt.Errorf(`invalid value %q for "%s": must contain '%s'`, template, field, KeyTemplateWildcard)
+	}
+	if strings.Trim(template, KeyTemplateWildcard) == "" {
+		return fmt.Errorf(`invalid value %q for "%s": must contain a static prefix or suffix`, template, field)
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package options
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/helpers"
+)
+
+// ConfigError is a problem with an option in a configuration file
+type ConfigError struct {
+	Path string
+	// Line of the option in the configuration file, or 0 if the option was not set in the file
+	Line    int
+	Message string
+}
+
+func (e ConfigError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.Path, e.Line, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidateConfig strictly parses the configuration file read by InitYAML, reporting unknown fields and values of the wrong type,
+// then validates the options set by the configuration file, command line flags, and environment variables. The access token is not
+// required, so configuration files can be validated without one.
+func ValidateConfig() []ConfigError {
+	path := viper.ConfigFileUsed()
+	var files []configFile
+	if path != "" {
+		var err error
+		files, _, err = readConfigFiles(path, nil)
+		if err != nil {
+			var configErr ConfigError
+			if errors.As(err, &configErr) {
+				return []ConfigError{configErr}
+			}
+			return []ConfigError{{Path: path, Message: err.Error()}}
+		}
+		var errs []ConfigError
+		for _, f := range files {
+			errs = append(errs, checkNode(f.path, f.node, reflect.TypeOf(Options{}), "")...)
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+	}
+	// options are reported in the file with the highest precedence that sets them
+	configError := func(field, message string) ConfigError {
+		for i := len(files) - 1; i >= 0; i-- {
+			if line := fieldLine(files[i].node, field); line > 0 {
+				return ConfigError{Path: files[i].path, Line: line, Message: message}
+			}
+		}
+		return ConfigError{Path: path, Message: message}
+	}
+
+	opts, err := GetOptions()
+	if err != nil {
+		return []ConfigError{{Path: path, Message: err.Error()}}
+	}
+
+	errs := []ConfigError{}
+	aliasMessages := map[string]bool{}
+	checkAliases := func(field string, aliases []Alias) {
+		for i, a := range aliases {
+			if err := a.IsValid(); err != nil {
+				aliasField := fmt.Sprintf("%s[%d]", field, i)
+				errs = append(errs, configError(aliasField, fmt.Sprintf("invalid alias %q: %v", aliasField, err)))
+				aliasMessages[err.Error()] = true
+			}
+		}
+	}
+	checkAliases("aliases", opts.Aliases)
+	for i, project := range opts.Projects {
+		checkAliases(fmt.Sprintf("projects[%d].aliases", i), project.Aliases)
+	}
+	// Validate stops at the first invalid alias, which has already been reported
+	if err := opts.validate(false); err != nil && !aliasMessages[err.Error()] {
+		errs = append(errs, configError(errorField(err), err.Error()))
+	}
+	return errs
+}
+
+// checkNode reports the fields of a YAML node that are not options of type t, and values that cannot be decoded into t
+func checkNode(path string, n *yaml.Node, t reflect.Type, field string) []ConfigError {
+	if n == nil {
+		return nil
+	}
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!null" {
+		return nil
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	invalid := func(expected string) []ConfigError {
+		return []ConfigError{{Path: path, Line: n.Line, Message: fmt.Sprintf(`invalid value for "%s": expected %s`, field, expected)}}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if n.Kind != yaml.MappingNode {
+			return invalid("a mapping")
+		}
+		fields := configFields(t)
+		var errs []ConfigError
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			f, ok := findConfigField(fields, key.Value)
+			if !ok {
+				errs = append(errs, ConfigError{Path: path, Line: key.Line, Message: fmt.Sprintf("unknown field %q%s", joinField(field, key.Value), suggestField(fields, key.Value))})
+				continue
+			}
+			if f.commandLineOnly {
+				errs = append(errs, ConfigError{Path: path, Line: key.Line, Message: fmt.Sprintf("%q may only be set with a command line flag or environment variable", f.name)})
+				continue
+			}
+			errs = append(errs, checkNode(path, value, f.Type, joinField(field, f.name))...)
+		}
+		return errs
+	case reflect.Map:
+		if n.Kind != yaml.MappingNode {
+			return invalid("a mapping")
+		}
+		var errs []ConfigError
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			errs = append(errs, checkNode(path, n.Content[i+1], t.Elem(), joinField(field, n.Content[i].Value))...)
+		}
+		return errs
+	case reflect.Slice:
+		// a comma-separated string is decoded as a list of strings
+		if n.Kind == yaml.ScalarNode && t.Elem().Kind() == reflect.String {
+			return nil
+		}
+		if n.Kind != yaml.SequenceNode {
+			return invalid("a list")
+		}
+		var errs []ConfigError
+		for i, item := range n.Content {
+			errs = append(errs, checkNode(path, item, t.Elem(), fmt.Sprintf("%s[%d]", field, i))...)
+		}
+		return errs
+	case reflect.String:
+		if n.Kind != yaml.ScalarNode {
+			return invalid("a string")
+		}
+	case reflect.Int, reflect.Int64:
+		if _, err := strconv.ParseInt(n.Value, 0, 64); n.Kind != yaml.ScalarNode || err != nil {
+			return invalid("an integer")
+		}
+	case reflect.Bool:
+		if _, err := strconv.ParseBool(n.Value); n.Kind != yaml.ScalarNode || err != nil {
+			return invalid("true or false")
+		}
+	}
+	return nil
+}
+
+// findConfigField finds an option by name, ignoring case to match viper's behavior
+func findConfigField(fields []configField, name string) (configField, bool) {
+	for _, f := range fields {
+		if strings.EqualFold(f.name, name) {
+			return f, true
+		}
+	}
+	return configField{}, false
+}
+
+// suggestField suggests the option with the most similar name to an unknown field, if it is likely to be a typo
+func suggestField(fields []configField, name string) string {
+	best, suggestion := len(name)/3+1, ""
+	for _, f := range fields {
+		if f.commandLineOnly {
+			continue
+		}
+		if d := helpers.EditDistance(strings.ToLower(name), strings.ToLower(f.name)); d < best {
+			best, suggestion = d, f.name
+		}
+	}
+	if suggestion == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %q?", suggestion)
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+var quotedField = regexp.MustCompile(`for "([^"]+)"`)
+
+// errorField returns the option named by a validation error, e.g. `projects[0].delimiters` for `invalid value "<<" for "projects[0].delimiters"`
+func errorField(err error) string {
+	if match := quotedField.FindStringSubmatch(err.Error()); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+var fieldSegment = regexp.MustCompile(`^([^\[]*)((?:\[\d+\])*)$`)
+
+// fieldLine returns the line of an option in a YAML document, such as `projects[1].aliases[0]`. If the option is not set,
+// the line of the nearest parent option is returned, or 0 if no parent is set.
+func fieldLine(root *yaml.Node, field string) int {
+	if root == nil || field == "" {
+		return 0
+	}
+	line, n := 0, root
+	for _, segment := range strings.Split(field, ".") {
+		match := fieldSegment.FindStringSubmatch(segment)
+		if match == nil {
+			return line
+		}
+		if match[1] != "" {
+			value, key := mappingValue(n, match[1])
+			if value == nil {
+				return line
+			}
+			n, line = value, key.Line
+		}
+		for _, index := range strings.Split(strings.Trim(match[2], "[]"), "][") {
+			if index == "" {
+				continue
+			}
+			i, _ := strconv.Atoi(index)
+			if n.Kind != yaml.SequenceNode || i >= len(n.Content) {
+				return line
+			}
+			n = n.Content[i]
+			line = n.Line
+		}
+	}
+	return line
+}
+
+func mappingValue(n *yaml.Node, key string) (value, keyNode *yaml.Node) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if n.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if strings.EqualFold(n.Content[i].Value, key) {
+			return n.Content[i+1], n.Content[i]
+		}
+	}
+	return nil, nil
+}
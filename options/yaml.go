@@ -0,0 +1,340 @@
+package options
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// rawConfig holds the contents of the YAML configuration file with the original casing of all keys preserved.
+// viper lowercases every map key it reads, which breaks user-supplied maps such as literal alias flag keys.
+var rawConfig map[string]interface{}
+
+// configFiles are the configuration files merged into rawConfig, from highest to lowest precedence
+var configFiles []string
+
+// ConfigFiles returns the configuration files read by InitYAML: the configuration file of the repository, followed by the files it extends
+func ConfigFiles() []string {
+	return configFiles
+}
+
+// readRawConfig reads a configuration file, interpolating environment variables and merging the files it extends and includes.
+// It returns the merged configuration and the files that were read, from highest to lowest precedence.
+func readRawConfig(path string) (map[string]interface{}, []string, error) {
+	files, raw, err := readConfigFiles(path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[len(files)-1-i] = f.path
+	}
+	return raw, paths, nil
+}
+
+type configFile struct {
+	path string
+	// Root node of the file, or nil if the file is empty
+	node *yaml.Node
+}
+
+// readConfigFiles reads a configuration file and every file it extends or includes, and returns the files from lowest to highest
+// precedence with their merged configuration. Included files come first, as their options are only used if no other file sets them,
+// followed by the files that are extended and the file itself. extending holds the files that are being read, to detect cycles.
+func readConfigFiles(path string, extending []string) ([]configFile, map[string]interface{}, error) {
+	path = filepath.Clean(path)
+	for i, p := range extending {
+		if p == path {
+			return nil, nil, fmt.Errorf("configuration files extend or include each other: %s", strings.Join(append(extending[i:], path), " -> "))
+		}
+	}
+	/* #nosec */
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	raw := map[string]interface{}{}
+	if len(document.Content) == 0 {
+		return []configFile{{path: path}}, raw, nil
+	}
+	root := document.Content[0]
+	if err := interpolateEnv(path, root); err != nil {
+		return nil, nil, err
+	}
+	contents := map[string]interface{}{}
+	if err := root.Decode(&contents); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	read := func(other string) ([]configFile, map[string]interface{}, error) {
+		if !filepath.IsAbs(other) {
+			other = filepath.Join(filepath.Dir(path), other)
+		}
+		return readConfigFiles(other, append(extending, path))
+	}
+	var extended, included []configFile
+	for _, base := range configPaths(root, "extends") {
+		baseFiles, baseRaw, err := read(base)
+		if err != nil {
+			return nil, nil, err
+		}
+		extended = append(extended, baseFiles...)
+		mergeConfig(raw, baseRaw)
+	}
+	mergeConfig(raw, contents)
+	for _, include := range configPaths(root, "include") {
+		includedFiles, includedRaw, err := read(include)
+		if err != nil {
+			return nil, nil, err
+		}
+		included = append(included, includedFiles...)
+		includeConfig(raw, includedRaw)
+	}
+
+	files := append(included, extended...)
+	return append(files, configFile{path: path, node: root}), raw, nil
+}
+
+// configPaths returns the paths in the extends or include option of a configuration file, which is either a single path or a list of paths
+func configPaths(root *yaml.Node, field string) []string {
+	value, _ := mappingValue(root, field)
+	if value == nil {
+		return nil
+	}
+	var paths []string
+	if value.Kind == yaml.ScalarNode {
+		if value.Value != "" {
+			paths = append(paths, value.Value)
+		}
+		return paths
+	}
+	for _, item := range value.Content {
+		if item.Kind == yaml.ScalarNode && item.Value != "" {
+			paths = append(paths, item.Value)
+		}
+	}
+	return paths
+}
+
+// mergeConfig merges a configuration into dst. Mappings are merged, and all other values in src replace the values in dst.
+// Option names are matched ignoring case, like viper does, so `contextlines` replaces `contextLines`.
+func mergeConfig(dst, src map[string]interface{}) {
+	for key, value := range src {
+		dstKey, ok := findKey(dst, key)
+		if ok {
+			srcMap, srcIsMap := value.(map[string]interface{})
+			dstMap, dstIsMap := dst[dstKey].(map[string]interface{})
+			if srcIsMap && dstIsMap {
+				mergeConfig(dstMap, srcMap)
+				continue
+			}
+			delete(dst, dstKey)
+		}
+		dst[key] = value
+	}
+}
+
+// includeConfig merges an included configuration into dst. Lists are appended to the lists in dst, mappings are merged, and all other
+// values are only used if dst does not set them.
+func includeConfig(dst, src map[string]interface{}) {
+	for key, value := range src {
+		dstKey, ok := findKey(dst, key)
+		if !ok {
+			dst[key] = value
+			continue
+		}
+		switch srcValue := value.(type) {
+		case map[string]interface{}:
+			if dstMap, ok := dst[dstKey].(map[string]interface{}); ok {
+				includeConfig(dstMap, srcValue)
+			}
+		case []interface{}:
+			if dstList, ok := dst[dstKey].([]interface{}); ok {
+				dst[dstKey] = append(append([]interface{}{}, dstList...), srcValue...)
+			}
+		}
+	}
+}
+
+// envReference matches references to environment variables such as ${NAME} or ${NAME:-default}, and the escaped form $${
+var envReference = regexp.MustCompile(`\$\$\{|\$\{([A-Z_][A-Z0-9_]*)(?::-([^}]*))?\}`)
+
+// interpolateEnv replaces references to environment variables in the values of a YAML node. Variables with a default value are replaced
+// with the default if they are unset or empty, and all other variables must be set.
+func interpolateEnv(path string, n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			if err := interpolateEnv(path, n.Content[i]); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			if err := interpolateEnv(path, item); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if !strings.Contains(n.Value, "${") {
+			return nil
+		}
+		var err error
+		n.Value = envReference.ReplaceAllStringFunc(n.Value, func(reference string) string {
+			if reference == "$${" {
+				return "${"
+			}
+			match := envReference.FindStringSubmatch(reference)
+			if value := os.Getenv(match[1]); value != "" {
+				return value
+			}
+			if strings.Contains(reference, ":-") {
+				return match[2]
+			}
+			if _, ok := os.LookupEnv(match[1]); !ok && err == nil {
+				err = ConfigError{Path: path, Line: n.Line, Message: fmt.Sprintf("environment variable %q is not set", match[1])}
+			}
+			return ""
+		})
+		// resolve the type of plain values after interpolation, e.g. `contextLines: ${CONTEXT_LINES}`
+		if n.Style == 0 {
+			n.Tag = ""
+		}
+		return err
+	}
+	return nil
+}
+
+// restoreMapKeyCase re-decodes every option containing a map from the raw YAML configuration, so map keys
+// keep the casing used in the configuration file. Options containing maps can only be configured via YAML, so
+// values set by command line flags or environment variables are never overwritten.
+func restoreMapKeyCase(opts *Options, raw map[string]interface{}) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	v := reflect.ValueOf(opts).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !containsMap(field.Type) {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+		value, ok := lookupKey(raw, name)
+		if !ok {
+			continue
+		}
+		target := reflect.New(field.Type)
+		if err := decode(value, target.Interface()); err != nil {
+			return err
+		}
+		v.Field(i).Set(target.Elem())
+	}
+	return nil
+}
+
+// lookupKey finds a key in a YAML map, ignoring case to match viper's behavior
+func lookupKey(raw map[string]interface{}, key string) (interface{}, bool) {
+	if k, ok := findKey(raw, key); ok {
+		return raw[k], true
+	}
+	return nil, false
+}
+
+// findKey returns the key of a YAML map matching key, ignoring case to match viper's behavior
+func findKey(raw map[string]interface{}, key string) (string, bool) {
+	if _, ok := raw[key]; ok {
+		return key, true
+	}
+	for k := range raw {
+		if strings.EqualFold(k, key) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// decode uses the same mapstructure configuration as viper.Unmarshal
+func decode(input, output interface{}) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+		),
+		Result: output,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(input)
+}
+
+func containsMap(t reflect.Type) bool {
+	return containsMapVisited(t, map[reflect.Type]bool{})
+}
+
+func containsMapVisited(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if visited[t] {
+		return false
+	}
+	visited[t] = true
+	switch t.Kind() {
+	case reflect.Map:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return containsMapVisited(t.Elem(), visited)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if containsMapVisited(t.Field(i).Type, visited) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ForSubdirectory returns the options for a subdirectory of the repository containing its own .launchdarkly/coderefs.yaml file.
+// Options set in the subdirectory's configuration file replace the options of o, and all other options are inherited.
+func (o Options) ForSubdirectory(subdirectory string) (Options, error) {
+	raw, _, err := readRawConfig(filepath.Join(o.Dir, subdirectory, ".launchdarkly", "coderefs.yaml"))
+	if err != nil {
+		return o, err
+	}
+	var nested Options
+	if err := decode(raw, &nested); err != nil {
+		return o, err
+	}
+
+	merged := o
+	v, nestedValue := reflect.ValueOf(&merged).Elem(), reflect.ValueOf(nested)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("mapstructure"), ",")[0]
+		if _, ok := lookupKey(raw, name); ok && !inheritedOnly[name] {
+			v.Field(i).Set(nestedValue.Field(i))
+		}
+	}
+	// a project key and projects cannot be combined, so projects in the subdirectory replace the project key of the root, and vice versa
+	if _, ok := lookupKey(raw, "projects"); ok {
+		merged.ProjKey = nested.ProjKey
+	} else if _, ok := lookupKey(raw, "projKey"); ok {
+		merged.Projects = nested.Projects
+	}
+	merged.Subdirectory = filepath.ToSlash(subdirectory)
+	merged.Discover = false
+	return merged, nil
+}
+
+// options that are always inherited from the root configuration
+var inheritedOnly = map[string]bool{"dir": true, "subdirectory": true, "discover": true}
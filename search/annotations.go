@@ -0,0 +1,62 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// Suppresses references on the line containing the annotation
+	ignoreLineAnnotation = "ld-coderefs-ignore"
+	// Suppresses references in the file containing the annotation
+	ignoreFileAnnotation = "ld-coderefs-ignore-file"
+)
+
+// flagAnnotation declares references to one or more comma-separated flag keys, e.g. `// ld-flag: new-checkout, dark-mode`
+var flagAnnotation = regexp.MustCompile(`\bld-flag:\s*([A-Za-z0-9._\-]+(?:\s*,\s*[A-Za-z0-9._\-]+)*)`)
+
+// IsIgnoredLine returns true if references in the line are suppressed with an ld-coderefs-ignore annotation
+func IsIgnoredLine(line string) bool {
+	return strings.Contains(line, ignoreLineAnnotation)
+}
+
+// IsIgnoredFile returns true if references in the file are suppressed with an ld-coderefs-ignore-file annotation
+func IsIgnoredFile(lines []string) bool {
+	for _, line := range lines {
+		if strings.Contains(line, ignoreFileAnnotation) {
+			return true
+		}
+	}
+	return false
+}
+
+// annotatedKeys returns the flag keys declared by `ld-flag` annotations in a line
+func annotatedKeys(line string) []string {
+	keys := make([]string, 0)
+	for _, submatch := range flagAnnotation.FindAllStringSubmatch(line, -1) {
+		for _, key := range strings.Split(submatch[1], ",") {
+			keys = append(keys, strings.TrimSpace(key))
+		}
+	}
+	return keys
+}
+
+func isAnnotated(line, element string) bool {
+	for _, key := range annotatedKeys(line) {
+		if key == element {
+			return true
+		}
+	}
+	return false
+}
+
+// findAnnotations returns the elements declared by `ld-flag` annotations in a line
+func (m ElementMatcher) findAnnotations(line string) []string {
+	elements := make([]string, 0)
+	for _, key := range annotatedKeys(line) {
+		if _, ok := m.elementSet[key]; ok {
+			elements = append(elements, key)
+		}
+	}
+	return elements
+}
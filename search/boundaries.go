@@ -0,0 +1,54 @@
+package search
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// DefaultIdentifierChars are the characters that are part of identifiers in most languages
+const DefaultIdentifierChars = `\p{L}\p{N}_$`
+
+// identifierChars is a character class describing the characters that may be part of an identifier
+type identifierChars struct {
+	ascii [utf8.RuneSelf]bool
+	class *regexp.Regexp
+}
+
+// newIdentifierChars compiles a regular expression character class, e.g. `A-Za-z0-9_`. ASCII characters are looked up in a table,
+// so checking word boundaries does not change the cost of matching.
+func newIdentifierChars(class string) (*identifierChars, error) {
+	if class == "" {
+		class = DefaultIdentifierChars
+	}
+	re, err := regexp.Compile("^[" + class + "]$")
+	if err != nil {
+		return nil, err
+	}
+	c := identifierChars{class: re}
+	for r := rune(0); r < utf8.RuneSelf; r++ {
+		c.ascii[r] = re.MatchString(string(r))
+	}
+	return &c, nil
+}
+
+func (c *identifierChars) contains(r rune) bool {
+	if r < utf8.RuneSelf {
+		return c.ascii[r]
+	}
+	return r != utf8.RuneError && c.class.MatchString(string(r))
+}
+
+// atWordBoundary returns true if the characters before and after line[start:end] are not identifier characters
+func (c *identifierChars) atWordBoundary(line string, start, end int) bool {
+	if start > 0 {
+		if r, _ := utf8.DecodeLastRuneInString(line[:start]); c.contains(r) {
+			return false
+		}
+	}
+	if end < len(line) {
+		if r, _ := utf8.DecodeRuneInString(line[end:]); c.contains(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+package search
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/lang"
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/ld"
+)
+
+var testPathPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(^|/)(test|tests|__tests__|__mocks__|spec|specs|testdata)/`),
+	regexp.MustCompile(`_test\.(go|py|rb)$`),
+	regexp.MustCompile(`\.(test|spec)\.[a-z]+$`),
+	regexp.MustCompile(`(^|/)test_[^/]*\.py$`),
+	regexp.MustCompile(`_spec\.rb$`),
+	regexp.MustCompile(`(Test|Tests|Spec|IT)\.(java|kt|cs|swift)$`),
+}
+
+var configExtensions = map[string]bool{
+	".json": true, ".yaml": true, ".yml": true, ".toml": true, ".ini": true, ".properties": true,
+	".env": true, ".conf": true, ".cfg": true, ".xml": true, ".plist": true, ".tf": true, ".tfvars": true,
+}
+
+// kindRank orders kinds when hunks with different kinds are merged. A hunk containing any evaluation is an evaluation,
+// while a hunk is only a comment if every reference in it is in a comment.
+var kindRank = map[string]int{
+	ld.TestKind:        5, //nolint:mnd
+	ld.ConfigKind:      4, //nolint:mnd
+	ld.EvaluationKind:  3, //nolint:mnd
+	ld.DeclarationKind: 2, //nolint:mnd
+	"":                 1,
+	ld.CommentKind:     0,
+}
+
+// classifier assigns a kind to each line of a file containing a reference
+type classifier struct {
+	// kind of every line in the file that is not a comment
+	fileKind     string
+	language     *lang.Language
+	lines        []string
+	commentLines map[int]bool
+}
+
+func newClassifier(f file) *classifier {
+	c := classifier{language: lang.ForPath(f.path), lines: f.lines}
+	switch {
+	case isTestPath(f.path):
+		c.fileKind = ld.TestKind
+	case isConfigPath(f.path):
+		c.fileKind = ld.ConfigKind
+	}
+	return &c
+}
+
+// kind returns the kind of a line. lineNum is 0-based.
+func (c *classifier) kind(lineNum int, line string) string {
+	if c.inComment(lineNum) {
+		return ld.CommentKind
+	}
+	return c.codeKind(line)
+}
+
+// codeKind returns the kind of a line, without checking if it is a comment
+func (c *classifier) codeKind(line string) string {
+	switch {
+	case c.fileKind != "":
+		return c.fileKind
+	case c.language == nil:
+		return ""
+	case c.language.HasEvaluation(line):
+		return ld.EvaluationKind
+	case len(lang.FindStringAssignments(c.language, line)) > 0:
+		return ld.DeclarationKind
+	}
+	return ""
+}
+
+// inComment returns true if a line only contains comments. lineNum is 0-based.
+func (c *classifier) inComment(lineNum int) bool {
+	if c.language == nil {
+		return false
+	}
+	// files are only tokenized once they are known to contain a reference
+	if c.commentLines == nil {
+		c.commentLines = lang.CommentLines(c.language, strings.Join(c.lines, "\n"))
+	}
+	return c.commentLines[lineNum+1]
+}
+
+// CommentLines returns the 1-based numbers of the lines of the file at path that only contain comments, using the comment syntax of
+// the language of the file. It returns nil if the language is not known.
+func CommentLines(path string, lines []string) map[int]bool {
+	language := lang.ForPath(path)
+	if language == nil {
+		return nil
+	}
+	return lang.CommentLines(language, strings.Join(lines, "\n"))
+}
+
+func isTestPath(p string) bool {
+	for _, re := range testPathPatterns {
+		if re.MatchString(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func isConfigPath(p string) bool {
+	base := path.Base(p)
+	return configExtensions[strings.ToLower(path.Ext(base))] || strings.HasPrefix(base, ".env")
+}
+
+// mergeKinds returns the kind of a hunk combining references of both kinds
+func mergeKinds(a, b string) string {
+	if kindRank[b] > kindRank[a] {
+		return b
+	}
+	return a
+}
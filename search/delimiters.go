@@ -5,15 +5,52 @@ import (
 	"github.com/launchdarkly/ld-find-code-refs/v2/options"
 )
 
-// Get a list of delimiters to use for flag key matching
-// If defaults are disabled, only additional configured delimiters will be used
-func GetDelimiters(opts options.Options) []string {
-	delims := []string{`"`, `'`, "`"}
-	if opts.Delimiters.DisableDefaults {
-		delims = []string{}
+var defaultDelimiters = []string{`"`, `'`, "`"}
+
+// GetDelimiterPairs returns the left and right delimiters to use for flag key matching.
+// Default delimiters are only paired with themselves, so `"key'` is not matched. Additional delimiters may be paired with
+// any default or additional delimiter, so `"key<` is matched, while configured pairs are only matched together.
+func GetDelimiterPairs(delimiters options.Delimiters) []options.DelimiterPair {
+	defaults := defaultDelimiters
+	if delimiters.DisableDefaults {
+		defaults = nil
+	}
+	isAdditional := make(map[string]bool, len(delimiters.Additional))
+	for _, delim := range delimiters.Additional {
+		isAdditional[delim] = true
+	}
+	for _, delim := range defaults {
+		isAdditional[delim] = false
 	}
 
-	delims = append(delims, opts.Delimiters.Additional...)
+	pairs := []options.DelimiterPair{}
+	for _, delim := range defaults {
+		pairs = append(pairs, options.DelimiterPair{Left: delim, Right: delim})
+	}
+	for _, pair := range crossDelimiterPairs(helpers.Dedupe(append(append([]string{}, defaults...), delimiters.Additional...))) {
+		if isAdditional[pair.Left] || isAdditional[pair.Right] {
+			pairs = append(pairs, pair)
+		}
+	}
+	pairs = append(pairs, delimiters.Pairs...)
 
-	return helpers.Dedupe(delims)
+	seen := make(map[options.DelimiterPair]struct{}, len(pairs))
+	ret := make([]options.DelimiterPair, 0, len(pairs))
+	for _, pair := range pairs {
+		if _, ok := seen[pair]; !ok {
+			seen[pair] = struct{}{}
+			ret = append(ret, pair)
+		}
+	}
+	return ret
+}
+
+func crossDelimiterPairs(delimiters []string) []options.DelimiterPair {
+	pairs := make([]options.DelimiterPair, 0, len(delimiters)*len(delimiters))
+	for _, left := range delimiters {
+		for _, right := range delimiters {
+			pairs = append(pairs, options.DelimiterPair{Left: left, Right: right})
+		}
+	}
+	return pairs
 }
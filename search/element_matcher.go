@@ -1,7 +1,12 @@
 package search
 
 import (
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+
 	"github.com/launchdarkly/ld-find-code-refs/v2/internal/helpers"
+	"github.com/launchdarkly/ld-find-code-refs/v2/options"
 	ahocorasick "github.com/petar-dambovaliev/aho-corasick"
 )
 
@@ -12,71 +17,330 @@ type ElementMatcher struct {
 	allElementAndAliasesMatcher ahocorasick.AhoCorasick
 	matcherByElement            map[string]ahocorasick.AhoCorasick
 	aliasMatcherByElement       map[string]ahocorasick.AhoCorasick
+	// Aliases of each element, in the order of the patterns of its alias matcher
+	aliasesByElement map[string][]string
+	// Whether elements and aliases are matched ignoring ASCII case
+	caseInsensitive bool
+	// Paths or globs where scoped aliases may be used, by element and alias
+	aliasScopesByElement map[string]map[string][]string
+	// If set, patterns without delimiters only match at word boundaries
+	identifierChars *identifierChars
+	// Delimiters surrounding elements, in the order of the patterns of each element matcher
+	delimiters []options.DelimiterPair
+	// Regular expressions matching references, evaluated in addition to delimited elements
+	referencePatterns []referencePattern
+	// Templates of flag keys built at runtime
+	keyTemplates []keyTemplate
+	// Replaces the context lines of the matcher, if set
+	contextLines *int
+	// Globs of directories and files owned by the project, relative to the repository root
+	paths []string
+	// Globs of files searched and not searched, relative to the repository root
+	include []string
+	ignore  []string
+	// Globs of directories and files of the code reference repository being searched, and of other code reference repositories
+	repositoryPaths      []string
+	otherRepositoryPaths []string
+	// Subdirectory of the configuration of the matcher, and the subdirectories of configurations nested in it, relative to the repository root
+	scopeDir        string
+	nestedScopeDirs []string
 
+	elementSet map[string]struct{}
+	// Keys of every flag in the project, including flags that are not searched for. Elements are used if not set.
+	flagKeys []string
+	// Delimited elements and aliases searched for, with the elements referenced by each
+	patterns               []string
 	elementsByPatternIndex [][]string
+	// Whether each pattern is an element or alias without delimiters
+	barePatternIndexes []bool
+}
+
+// knownFlagKeys returns the keys of every flag in the project, so flags that are not searched for are not unknown
+func (m ElementMatcher) knownFlagKeys() []string {
+	if m.flagKeys != nil {
+		return m.flagKeys
+	}
+	return m.Elements
+}
+
+// SearchesPath returns true if the file at path is in the directory of the matcher, is in or matches one of its paths if any are set,
+// matches an include glob if any are set, and does not match an ignore glob
+func (m ElementMatcher) SearchesPath(path string) bool {
+	if m.Dir != "" && !inDir(m.Dir, path) {
+		return false
+	}
+	if len(m.paths) > 0 && !matchesAnyPath(m.paths, path) {
+		return false
+	}
+	if len(m.include) > 0 && !matchesAnyGlob(m.include, path) {
+		return false
+	}
+	if len(m.repositoryPaths) > 0 && !matchesAnyPath(m.repositoryPaths, path) || matchesAnyPath(m.otherRepositoryPaths, path) {
+		return false
+	}
+	if m.scopeDir != "" && !inDir(m.scopeDir, path) {
+		return false
+	}
+	for _, dir := range m.nestedScopeDirs {
+		if inDir(dir, path) {
+			return false
+		}
+	}
+	return !matchesAnyGlob(m.ignore, path)
+}
+
+// inDir returns true if path is dir or in dir, comparing whole path segments so `web` does not contain `webhooks/app.js`
+func inDir(dir, path string) bool {
+	dir = strings.TrimSuffix(dir, "/")
+	return path == dir || strings.HasPrefix(path, dir+"/")
+}
+
+// MatchesPaths returns true if path matches one of the globs, or is in a directory matching one of the globs
+func MatchesPaths(globs []string, path string) bool {
+	return matchesAnyPath(globs, path)
+}
+
+// matchesAnyPath returns true if path matches one of the globs, or is in a directory matching one of the globs
+func matchesAnyPath(globs []string, path string) bool {
+	for _, glob := range globs {
+		glob = strings.TrimSuffix(glob, "/")
+		if matched, _ := doublestar.Match(glob, path); matched {
+			return true
+		}
+		if matched, _ := doublestar.Match(glob+"/**", path); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAnyGlob(globs []string, path string) bool {
+	for _, glob := range globs {
+		if matched, _ := doublestar.Match(glob, path); matched {
+			return true
+		}
+	}
+	return false
 }
 
-func (m ElementMatcher) FindMatches(line string) []string {
+// FindMatches returns the elements referenced in a line of the file at path. Scoped aliases only reference their element if the path is in scope.
+func (m ElementMatcher) FindMatches(path, line string) []string {
+	return m.findMatches(path, line, m.findReferences(line))
+}
+
+// FindExactMatches returns the elements referenced in a line of the file at path, without low-confidence references through key templates
+func (m ElementMatcher) FindExactMatches(path, line string) []string {
+	return helpers.Dedupe(m.findExactMatches(path, line, m.findReferences(line)))
+}
+
+// findMatches returns the elements referenced in a line, given the elements captured by reference patterns in the line
+func (m ElementMatcher) findMatches(path, line string, references []string) []string {
+	elements := m.findExactMatches(path, line, references)
+	elements = append(elements, m.findTemplateReferences(line)...)
+	return helpers.Dedupe(elements)
+}
+
+func (m ElementMatcher) findExactMatches(path, line string, references []string) []string {
 	elements := make([]string, 0)
 	iter := m.allElementAndAliasesMatcher.IterOverlapping(line)
 	for match := iter.Next(); match != nil; match = iter.Next() {
-		elements = append(elements, m.elementsByPatternIndex[match.Pattern()]...)
+		if m.barePatternIndexes[match.Pattern()] && !m.atWordBoundary(line, match) {
+			continue
+		}
+		for _, element := range m.elementsByPatternIndex[match.Pattern()] {
+			if m.aliasInScope(path, element, m.patterns[match.Pattern()]) {
+				elements = append(elements, element)
+			}
+		}
 	}
-	return helpers.Dedupe(elements)
+	elements = append(elements, references...)
+	return append(elements, m.findAnnotations(line)...)
 }
 
-func (m ElementMatcher) FindAliases(line, element string) []string {
+// MatchElement returns true if the line contains the element surrounded by delimiters, or an annotation declaring a reference to the element
+func (m ElementMatcher) MatchElement(line, element string) bool {
+	e, exists := m.matcherByElement[element]
+	if !exists || IsIgnoredLine(line) {
+		return false
+	}
+	if isAnnotated(line, element) {
+		return true
+	}
+	if len(m.referencePatterns) == 0 && (len(m.delimiters) > 0 || m.identifierChars == nil) {
+		return e.Iter(line).Next() != nil
+	}
+	return len(m.FindElementText(line, element)) > 0
+}
+
+// FindElementText returns the text of each match of the element in the line, without delimiters, including matches of reference patterns.
+// The text only differs from the element if matching is case-insensitive.
+func (m ElementMatcher) FindElementText(line, element string) []string {
+	return m.findElementText(line, element, m.findReferences(line))
+}
+
+// findElementText returns the text of each match of the element in the line, given the elements captured by reference patterns in the line
+func (m ElementMatcher) findElementText(line, element string, references []string) []string {
+	matches := make([]string, 0)
+	e, exists := m.matcherByElement[element]
+	if !exists {
+		return matches
+	}
+	for _, reference := range references {
+		if reference == element {
+			matches = append(matches, reference)
+		}
+	}
+	iter := e.IterOverlapping(line)
+	for match := iter.Next(); match != nil; match = iter.Next() {
+		start, end := match.Start(), match.End()
+		if len(m.delimiters) > 0 {
+			pair := m.delimiters[match.Pattern()]
+			start += len(pair.Left)
+			end -= len(pair.Right)
+		} else if !m.atWordBoundary(line, match) {
+			continue
+		}
+		matches = append(matches, line[start:end])
+	}
+	return helpers.Dedupe(matches)
+}
+
+// SetWordBoundaries limits elements matched without delimiters, and aliases, to matches that are not surrounded by identifier characters.
+// identifierChars is a regular expression character class, which defaults to DefaultIdentifierChars.
+func (m *ElementMatcher) SetWordBoundaries(identifierChars string) error {
+	chars, err := newIdentifierChars(identifierChars)
+	if err != nil {
+		return err
+	}
+	m.identifierChars = chars
+	return nil
+}
+
+func (m ElementMatcher) atWordBoundary(line string, match *ahocorasick.Match) bool {
+	return m.identifierChars == nil || m.identifierChars.atWordBoundary(line, match.Start(), match.End())
+}
+
+// FindAliases returns the aliases for an element found in a line of the file at path. Scoped aliases are only returned if the path is in scope.
+func (m ElementMatcher) FindAliases(path, line, element string) []string {
 	aliasMatches := make([]string, 0)
 	if aliasMatcher, exists := m.aliasMatcherByElement[element]; exists {
 		iter := aliasMatcher.IterOverlapping(line)
 		for match := iter.Next(); match != nil; match = iter.Next() {
-			aliasMatches = append(aliasMatches, line[match.Start():match.End()])
+			// Scopes are looked up by the configured alias, since the text differs in case when matching is case-insensitive
+			alias := m.aliasesByElement[element][match.Pattern()]
+			if m.aliasInScope(path, element, alias) && m.atWordBoundary(line, match) {
+				aliasMatches = append(aliasMatches, line[match.Start():match.End()])
+			}
 		}
 	}
 	return aliasMatches
 }
 
-func NewElementMatcher(projKey, dir, delimiters string, elements []string, aliasesByElement map[string][]string) ElementMatcher {
-	matcherBuilder := ahocorasick.NewAhoCorasickBuilder(ahocorasick.Opts{DFA: true, MatchKind: ahocorasick.StandardMatch})
+func (m ElementMatcher) aliasInScope(path, element, alias string) bool {
+	scopes, ok := m.aliasScopesByElement[element][alias]
+	if !ok {
+		return true
+	}
+	for _, scope := range scopes {
+		if scope == path {
+			return true
+		}
+		if matched, _ := doublestar.Match(scope, path); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func NewElementMatcher(projKey, dir string, delimiters []options.DelimiterPair, elements []string, aliasesByElement map[string][]string) ElementMatcher {
+	elementSet := make(map[string]struct{}, len(elements))
+	for _, element := range elements {
+		elementSet[element] = struct{}{}
+	}
+
+	m := ElementMatcher{
+		Elements:         elements,
+		ProjKey:          projKey,
+		Dir:              dir,
+		aliasesByElement: aliasesByElement,
+		delimiters:       delimiters,
+		elementSet:       elementSet,
+	}
+	m.buildMatchers()
+	return m
+}
+
+// SetCaseInsensitive enables ASCII case-insensitive matching of elements and aliases
+func (m *ElementMatcher) SetCaseInsensitive(caseInsensitive bool) {
+	if m.caseInsensitive != caseInsensitive {
+		m.caseInsensitive = caseInsensitive
+		m.buildMatchers()
+	}
+}
+
+// SetAliasScopes limits aliases to the paths or globs of their scopes, by element and alias
+func (m *ElementMatcher) SetAliasScopes(aliasScopesByElement map[string]map[string][]string) {
+	m.aliasScopesByElement = aliasScopesByElement
+}
+
+// SetFlagKeys sets the keys of every flag in the project, so flags that are not searched for are not reported as unknown
+func (m *ElementMatcher) SetFlagKeys(flagKeys []string) {
+	m.flagKeys = flagKeys
+}
+
+// SetContextLines replaces the context lines of the matcher, if contextLines is not nil
+func (m *ElementMatcher) SetContextLines(contextLines *int) {
+	m.contextLines = contextLines
+}
+
+// SetPaths limits the files searched to paths owned by the project and matching include, and not matching ignore
+func (m *ElementMatcher) SetPaths(paths, include, ignore []string) {
+	m.paths = paths
+	m.include = include
+	m.ignore = ignore
+}
+
+// buildMatchers builds the matchers of the delimited elements and aliases
+func (m *ElementMatcher) buildMatchers() {
+	matcherBuilder := ahocorasick.NewAhoCorasickBuilder(ahocorasick.Opts{DFA: true, MatchKind: ahocorasick.StandardMatch, AsciiCaseInsensitive: m.caseInsensitive})
 
 	allFlagPatternsAndAliases := make([]string, 0)
 	elementsByPatternIndex := make([][]string, 0)
+	barePatternIndexes := make([]bool, 0)
 	patternIndex := make(map[string]int)
 
-	recordPatternsForElement := func(element string, patterns []string) {
+	recordPatternsForElement := func(element string, patterns []string, bare bool) {
 		for _, p := range patterns {
 			index, exists := patternIndex[p]
 			if !exists {
 				allFlagPatternsAndAliases = append(allFlagPatternsAndAliases, p)
 				index = len(elementsByPatternIndex)
 				elementsByPatternIndex = append(elementsByPatternIndex, []string{})
+				barePatternIndexes = append(barePatternIndexes, false)
 			}
 			patternIndex[p] = index
 			elementsByPatternIndex[index] = append(elementsByPatternIndex[index], element)
+			barePatternIndexes[index] = barePatternIndexes[index] || bare
 		}
 	}
 
-	patternsByElement := buildElementPatterns(elements, delimiters)
+	patternsByElement := buildElementPatterns(m.Elements, m.delimiters)
 	flagMatcherByKey := make(map[string]ahocorasick.AhoCorasick, len(patternsByElement))
 	for element, patterns := range patternsByElement {
 		flagMatcherByKey[element] = matcherBuilder.Build(patterns)
-		recordPatternsForElement(element, patterns)
+		recordPatternsForElement(element, patterns, len(m.delimiters) == 0)
 	}
 
-	aliasMatcherByElement := make(map[string]ahocorasick.AhoCorasick, len(aliasesByElement))
-	for element, elementAliases := range aliasesByElement {
+	aliasMatcherByElement := make(map[string]ahocorasick.AhoCorasick, len(m.aliasesByElement))
+	for element, elementAliases := range m.aliasesByElement {
 		aliasMatcherByElement[element] = matcherBuilder.Build(elementAliases)
-		recordPatternsForElement(element, elementAliases)
+		recordPatternsForElement(element, elementAliases, true)
 	}
 
-	return ElementMatcher{
-		Elements:                    elements,
-		ProjKey:                     projKey,
-		Dir:                         dir,
-		matcherByElement:            flagMatcherByKey,
-		aliasMatcherByElement:       aliasMatcherByElement,
-		allElementAndAliasesMatcher: matcherBuilder.Build(allFlagPatternsAndAliases),
-
-		elementsByPatternIndex: elementsByPatternIndex,
-	}
+	m.matcherByElement = flagMatcherByKey
+	m.aliasMatcherByElement = aliasMatcherByElement
+	m.allElementAndAliasesMatcher = matcherBuilder.Build(allFlagPatternsAndAliases)
+	m.patterns = allFlagPatternsAndAliases
+	m.elementsByPatternIndex = elementsByPatternIndex
+	m.barePatternIndexes = barePatternIndexes
 }
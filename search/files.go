@@ -8,39 +8,12 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/monochromegane/go-gitignore"
 	"golang.org/x/tools/godoc/util"
 
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/ignore"
 	"github.com/launchdarkly/ld-find-code-refs/v2/internal/validation"
 )
 
-type ignore struct {
-	path    string
-	ignores []gitignore.IgnoreMatcher
-}
-
-func newIgnore(path string, ignoreFiles []string) ignore {
-	ignores := make([]gitignore.IgnoreMatcher, 0, len(ignoreFiles))
-	for _, ignoreFile := range ignoreFiles {
-		i, err := gitignore.NewGitIgnore(filepath.Join(path, ignoreFile))
-		if err != nil {
-			continue
-		}
-		ignores = append(ignores, i)
-	}
-	return ignore{path: path, ignores: ignores}
-}
-
-func (m ignore) Match(path string, isDir bool) bool {
-	for _, i := range m.ignores {
-		if i.Match(path, isDir) {
-			return true
-		}
-	}
-
-	return false
-}
-
 func readFileLines(path string) ([]string, error) {
 	if !validation.FileExists(path) {
 		return nil, errors.New("file does not exist")
@@ -65,9 +38,13 @@ func readFileLines(path string) ([]string, error) {
 }
 
 func readFiles(ctx context.Context, files chan<- file, workspace, subdirectory string) error {
+	allIgnores := ignore.New(workspace, ignore.Files)
+	return walkFiles(ctx, files, workspace, subdirectory, allIgnores.Match)
+}
+
+// walkFiles sends every text file in the workspace that is not hidden or ignored to the files channel, and closes it when done
+func walkFiles(ctx context.Context, files chan<- file, workspace, subdirectory string, ignored func(path string, isDir bool) bool) error {
 	defer close(files)
-	ignoreFiles := []string{".gitignore", ".ignore", ".ldignore"}
-	allIgnores := newIgnore(workspace, ignoreFiles)
 	workspace = filepath.ToSlash(workspace)
 
 	readFile := func(path string, info os.FileInfo, err error) error {
@@ -80,7 +57,7 @@ func readFiles(ctx context.Context, files chan<- file, workspace, subdirectory s
 		path = filepath.ToSlash(path)
 
 		// Skip directories, hidden files, and ignored files
-		if allIgnores.Match(path, isDir) {
+		if ignored(path, isDir) {
 			if isDir {
 				return filepath.SkipDir
 			}
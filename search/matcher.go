@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/launchdarkly/ld-find-code-refs/v2/aliases"
+	"github.com/launchdarkly/ld-find-code-refs/v2/flags"
 	"github.com/launchdarkly/ld-find-code-refs/v2/internal/helpers"
 	"github.com/launchdarkly/ld-find-code-refs/v2/internal/log"
 	"github.com/launchdarkly/ld-find-code-refs/v2/options"
@@ -12,42 +13,113 @@ import (
 type Matcher struct {
 	Elements []ElementMatcher
 	ctxLines int
+	comments options.CommentPolicy
 }
 
-func NewMultiProjectMatcher(opts options.Options, dir string, flagKeys map[string][]string) Matcher {
+func NewMultiProjectMatcher(opts options.Options, dir string, flagKeys flags.FlagKeys) Matcher {
 	elements := make([]ElementMatcher, 0, len(opts.Projects))
-	delimiters := strings.Join(GetDelimiters(opts), "")
 
 	for _, project := range opts.Projects {
-		projectFlags := flagKeys[project.Key]
-		projectAliases := opts.Aliases
-		projectAliases = append(projectAliases, project.Aliases...)
-		aliasesByFlagKey, err := aliases.GenerateAliases(projectFlags, projectAliases, dir)
+		projectFlags := flagKeys.Searched[project.Key]
+		projectAliases := opts.ProjectAliases(project)
+		generatedAliases, err := aliases.GenerateAliasList(projectFlags, projectAliases, dir)
 		if err != nil {
 			log.Error.Fatalf("failed to generate aliases: %s for project: %s", err, project.Key)
 		}
+		generatedAliases, err = aliases.ApplyCollisionPolicy(options.AliasCollisionPolicy(opts.AliasCollisions), projectFlags, generatedAliases)
+		if err != nil {
+			log.Error.Fatalf("%s for project: %s", err, project.Key)
+		}
+		aliasesByFlagKey := aliases.AliasesByFlagKey(projectFlags, generatedAliases)
 
-		elements = append(elements, NewElementMatcher(project.Key, project.Dir, delimiters, projectFlags, aliasesByFlagKey))
+		projectDelimiters := opts.ProjectDelimiters(project)
+		elementMatcher := NewElementMatcher(project.Key, project.Dir, GetDelimiterPairs(projectDelimiters), projectFlags, aliasesByFlagKey)
+		elementMatcher.SetCaseInsensitive(opts.CaseInsensitive)
+		elementMatcher.SetAliasScopes(aliases.AliasScopesByFlagKey(generatedAliases))
+		elementMatcher.SetFlagKeys(flagKeys.All[project.Key])
+		if err := elementMatcher.SetReferencePatterns(opts.ProjectReferencePatterns(project)); err != nil {
+			log.Error.Fatalf("%s for project: %s", err, project.Key)
+		}
+		elementMatcher.SetKeyTemplates(opts.ProjectKeyTemplates(project))
+		elementMatcher.SetContextLines(project.ContextLines)
+		elementMatcher.SetPaths(project.Paths, project.Include, project.Ignore)
+		if projectDelimiters.WordBoundaries {
+			if err := elementMatcher.SetWordBoundaries(projectDelimiters.IdentifierChars); err != nil {
+				log.Error.Fatalf("invalid identifier characters: %s for project: %s", err, project.Key)
+			}
+		}
+		elements = append(elements, elementMatcher)
 	}
 
 	return Matcher{
 		ctxLines: opts.ContextLines,
+		comments: options.CommentPolicy(opts.Comments).Canonical(),
 		Elements: elements,
 	}
 }
 
+// SkipsComments returns true if references on lines that only contain comments are tagged or ignored, so they do not keep a flag
+// from being removed
+func (m Matcher) SkipsComments() bool {
+	return m.comments == options.TagComments || m.comments == options.IgnoreComments
+}
+
 func (m Matcher) MatchElement(line, element string) bool {
 	for _, em := range m.Elements {
-		if e, exists := em.matcherByElement[element]; exists {
-			if e.Iter(line).Next() != nil {
-				return true
-			}
+		if em.MatchElement(line, element) {
+			return true
 		}
 	}
 
 	return false
 }
 
+// MatchKeyTemplate returns true if the line is a low-confidence reference to the element through a key template
+func (m Matcher) MatchKeyTemplate(line, element string) bool {
+	for _, em := range m.Elements {
+		if em.MatchKeyTemplate(line, element) {
+			return true
+		}
+	}
+	return false
+}
+
+// ForRepository returns a copy of the matcher that only searches files in a code reference repository. Files must be in or match paths,
+// if set, and must not be in or match the paths of other repositories.
+func (m Matcher) ForRepository(paths, otherRepositoryPaths []string) Matcher {
+	elements := make([]ElementMatcher, 0, len(m.Elements))
+	for _, em := range m.Elements {
+		em.repositoryPaths = paths
+		em.otherRepositoryPaths = otherRepositoryPaths
+		elements = append(elements, em)
+	}
+	m.Elements = elements
+	return m
+}
+
+// ForScope returns a copy of the matcher that only searches files in the subdirectory of its configuration, and not in the
+// subdirectories of nested configurations, which are searched by their own matchers
+func (m Matcher) ForScope(subdirectory string, nestedSubdirectories []string) Matcher {
+	elements := make([]ElementMatcher, 0, len(m.Elements))
+	for _, em := range m.Elements {
+		em.scopeDir = subdirectory
+		em.nestedScopeDirs = nestedSubdirectories
+		elements = append(elements, em)
+	}
+	m.Elements = elements
+	return m
+}
+
+// contextLines returns the number of context lines for references in the project
+func (m Matcher) contextLines(projKey string) int {
+	for _, em := range m.Elements {
+		if em.ProjKey == projKey && em.contextLines != nil {
+			return *em.contextLines
+		}
+	}
+	return m.ctxLines
+}
+
 func (m Matcher) GetProjectElementMatcher(projectKey string) *ElementMatcher {
 	var elementMatcher ElementMatcher
 	for _, element := range m.Elements {
@@ -59,10 +131,20 @@ func (m Matcher) GetProjectElementMatcher(projectKey string) *ElementMatcher {
 	return &elementMatcher
 }
 
-func (m Matcher) FindAliases(line, element string) []string {
+// findElementText returns the text of each match of the element in the line, without delimiters, given the elements captured by
+// reference patterns in the line
+func (m Matcher) findElementText(line, element string, references []string) []string {
+	matches := make([]string, 0)
+	for _, em := range m.Elements {
+		matches = append(matches, em.findElementText(line, element, references)...)
+	}
+	return helpers.Dedupe(matches)
+}
+
+func (m Matcher) FindAliases(path, line, element string) []string {
 	matches := make([]string, 0)
 	for _, em := range m.Elements {
-		matches = append(matches, em.FindAliases(line, element)...)
+		matches = append(matches, em.FindAliases(path, line, element)...)
 	}
 	return helpers.Dedupe(matches)
 }
@@ -74,21 +156,19 @@ func (m Matcher) GetElements() (elements [][]string) {
 	return elements
 }
 
-func buildElementPatterns(flags []string, delimiters string) map[string][]string {
+func buildElementPatterns(flags []string, delimiters []options.DelimiterPair) map[string][]string {
 	patternsByFlag := make(map[string][]string, len(flags))
 	for _, flag := range flags {
 		var patterns []string
-		if delimiters != "" {
-			patterns = make([]string, 0, len(delimiters)*len(delimiters))
-			for _, left := range delimiters {
-				for _, right := range delimiters {
-					var sb strings.Builder
-					sb.Grow(len(flag) + 2) //nolint:mnd
-					sb.WriteRune(left)
-					sb.WriteString(flag)
-					sb.WriteRune(right)
-					patterns = append(patterns, sb.String())
-				}
+		if len(delimiters) > 0 {
+			patterns = make([]string, 0, len(delimiters))
+			for _, pair := range delimiters {
+				var sb strings.Builder
+				sb.Grow(len(pair.Left) + len(flag) + len(pair.Right))
+				sb.WriteString(pair.Left)
+				sb.WriteString(flag)
+				sb.WriteString(pair.Right)
+				patterns = append(patterns, sb.String())
 			}
 		} else {
 			patterns = []string{flag}
@@ -1,33 +1,274 @@
 package search
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/launchdarkly/ld-find-code-refs/v2/options"
 )
 
+// delimiterPairs pairs every delimiter character with every other, like additional delimiters
+func delimiterPairs(delimiters string) []options.DelimiterPair {
+	return crossDelimiterPairs(strings.Split(delimiters, ""))
+}
+
 func Test_buildFlagPatterns(t *testing.T) {
 	testFlagKey := "testflag"
-	patterns := buildElementPatterns([]string{testFlagKey}, defaultDelims)
+	patterns := buildElementPatterns([]string{testFlagKey}, delimiterPairs(defaultDelims))
 	want := map[string][]string{"testflag": {"\"testflag\"", "\"testflag'", "\"testflag`", "'testflag\"", "'testflag'", "'testflag`", "`testflag\"", "`testflag'", "`testflag`"}}
 	require.Equal(t, want, patterns)
+
+	pairs := []options.DelimiterPair{{Left: "{{ flag \"", Right: "\" }}"}, {Left: "${", Right: "}"}}
+	patterns = buildElementPatterns([]string{testFlagKey}, pairs)
+	want = map[string][]string{"testflag": {"{{ flag \"testflag\" }}", "${testflag}"}}
+	require.Equal(t, want, patterns)
+}
+
+func TestGetDelimiterPairs(t *testing.T) {
+	specs := []struct {
+		name       string
+		delimiters options.Delimiters
+		want       []options.DelimiterPair
+	}{
+		{
+			name:       "defaults",
+			delimiters: options.Delimiters{},
+			want:       []options.DelimiterPair{{Left: `"`, Right: `"`}, {Left: "'", Right: "'"}, {Left: "`", Right: "`"}},
+		},
+		{
+			name:       "defaults and additional",
+			delimiters: options.Delimiters{Additional: []string{"<", ">", `"`}},
+			want: []options.DelimiterPair{
+				{Left: `"`, Right: `"`}, {Left: "'", Right: "'"}, {Left: "`", Right: "`"},
+				{Left: `"`, Right: "<"}, {Left: `"`, Right: ">"}, {Left: "'", Right: "<"}, {Left: "'", Right: ">"}, {Left: "`", Right: "<"}, {Left: "`", Right: ">"},
+				{Left: "<", Right: `"`}, {Left: "<", Right: "'"}, {Left: "<", Right: "`"}, {Left: "<", Right: "<"}, {Left: "<", Right: ">"},
+				{Left: ">", Right: `"`}, {Left: ">", Right: "'"}, {Left: ">", Right: "`"}, {Left: ">", Right: "<"}, {Left: ">", Right: ">"},
+			},
+		},
+		{
+			name:       "pairs only",
+			delimiters: options.Delimiters{DisableDefaults: true, Pairs: []options.DelimiterPair{{Left: "[[", Right: "]]"}, {Left: "[[", Right: "]]"}}},
+			want:       []options.DelimiterPair{{Left: "[[", Right: "]]"}},
+		},
+		{
+			name:       "additional and pairs",
+			delimiters: options.Delimiters{DisableDefaults: true, Additional: []string{"<", ">"}, Pairs: []options.DelimiterPair{{Left: "${", Right: "}"}, {Left: "<", Right: ">"}}},
+			want:       append(delimiterPairs("<>"), options.DelimiterPair{Left: "${", Right: "}"}),
+		},
+	}
+	for _, tt := range specs {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, GetDelimiterPairs(tt.delimiters))
+		})
+	}
+}
+
+func TestGetDelimiterPairs_mismatchedDefaults(t *testing.T) {
+	matcher := NewElementMatcher("project", "", GetDelimiterPairs(options.Delimiters{}), []string{"my-flag"}, nil)
+
+	assert.Equal(t, []string{"my-flag"}, matcher.FindMatches("", `flag("my-flag")`))
+	assert.Empty(t, matcher.FindMatches("", `flag("my-flag')`))
+	assert.Empty(t, matcher.FindMatches("", "flag('my-flag`)"))
+
+	matcher = NewElementMatcher("project", "", GetDelimiterPairs(options.Delimiters{Additional: []string{"<"}}), []string{"my-flag"}, nil)
+	assert.Equal(t, []string{"my-flag"}, matcher.FindMatches("", `"my-flag<`))
+	assert.Equal(t, []string{"my-flag"}, matcher.FindMatches("", `<my-flag'`))
+	assert.Empty(t, matcher.FindMatches("", `"my-flag'`))
 }
 
 func TestElementMatcher_FindAliases(t *testing.T) {
 	t.Run("overlapping aliases are reported separately", func(t *testing.T) {
-		matcher := NewElementMatcher("project", "", "", nil, map[string][]string{"flag": {"alias", "alias1"}})
-		assert.ElementsMatch(t, []string{"alias", "alias1"}, matcher.FindAliases("alias1", "flag"))
+		matcher := NewElementMatcher("project", "", nil, nil, map[string][]string{"flag": {"alias", "alias1"}})
+		assert.ElementsMatch(t, []string{"alias", "alias1"}, matcher.FindAliases("", "alias1", "flag"))
+	})
+	t.Run("scoped aliases are only reported in scope", func(t *testing.T) {
+		matcher := NewElementMatcher("project", "", nil, nil, map[string][]string{"flag": {"FLAG", "globalFlag"}})
+		matcher.SetAliasScopes(map[string]map[string][]string{"flag": {"FLAG": {"src/flags.go", "lib/**/*.js"}}})
+		assert.ElementsMatch(t, []string{"FLAG", "globalFlag"}, matcher.FindAliases("src/flags.go", "FLAG globalFlag", "flag"))
+		assert.Equal(t, []string{"flag"}, matcher.FindMatches("src/flags.go", "FLAG"))
+		assert.Empty(t, matcher.FindMatches("src/other.go", "FLAG"))
+		assert.ElementsMatch(t, []string{"FLAG", "globalFlag"}, matcher.FindAliases("lib/a/b.js", "FLAG globalFlag", "flag"))
+		assert.ElementsMatch(t, []string{"globalFlag"}, matcher.FindAliases("src/other.go", "FLAG globalFlag", "flag"))
+	})
+	t.Run("scoped aliases are only reported in scope when matching is case-insensitive", func(t *testing.T) {
+		matcher := NewElementMatcher("project", "", nil, nil, map[string][]string{"flag": {"FLAG"}})
+		matcher.SetCaseInsensitive(true)
+		matcher.SetAliasScopes(map[string]map[string][]string{"flag": {"FLAG": {"src/flags.go"}}})
+		assert.Equal(t, []string{"flag"}, matcher.FindAliases("src/flags.go", "flag", "flag"))
+		assert.Empty(t, matcher.FindAliases("src/other.go", "flag", "flag"))
+		assert.Empty(t, matcher.FindMatches("src/other.go", "Flag"))
 	})
 }
 
 func TestElementMatcher_FindMatches(t *testing.T) {
 	t.Run("overlapping flags are reported separately", func(t *testing.T) {
-		matcher := NewElementMatcher("project", "", "", []string{"flag", "flag1"}, nil)
-		assert.ElementsMatch(t, []string{"flag", "flag1"}, matcher.FindMatches("flag1"))
+		matcher := NewElementMatcher("project", "", nil, []string{"flag", "flag1"}, nil)
+		assert.ElementsMatch(t, []string{"flag", "flag1"}, matcher.FindMatches("", "flag1"))
+	})
+}
+
+func TestElementMatcher_wordBoundaries(t *testing.T) {
+	matcher := NewElementMatcher("project", "", nil, []string{"beta"}, map[string][]string{"beta": {"BETA"}})
+	require.NoError(t, matcher.SetWordBoundaries(""))
+
+	specs := []struct {
+		name     string
+		line     string
+		expected []string
+	}{
+		{name: "inside identifier", line: "alphabetagamma BETAS", expected: []string{}},
+		{name: "surrounded by punctuation", line: "if (beta) {", expected: []string{"beta"}},
+		{name: "start and end of line", line: "beta", expected: []string{"beta"}},
+		{name: "later match at boundary", line: "alphabeta = beta", expected: []string{"beta"}},
+		{name: "alias at boundary", line: "x := BETA", expected: []string{"beta"}},
+		{name: "non-ASCII identifier", line: "éBETA", expected: []string{}},
+	}
+	for _, tt := range specs {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, matcher.FindMatches("", tt.line))
+			assert.Equal(t, len(tt.expected) > 0, matcher.MatchElement(tt.line, "beta") || len(matcher.FindAliases("", tt.line, "beta")) > 0)
+		})
+	}
+
+	t.Run("custom identifier characters", func(t *testing.T) {
+		require.NoError(t, matcher.SetWordBoundaries(`A-Za-z0-9_\-`))
+		assert.Empty(t, matcher.FindMatches("", "data-beta-test"))
+		assert.Empty(t, matcher.FindAliases("", "data-BETA", "beta"))
+		assert.True(t, matcher.MatchElement("beta.enabled", "beta"))
+	})
+
+	t.Run("delimited elements are not filtered", func(t *testing.T) {
+		delimited := NewElementMatcher("project", "", delimiterPairs(`"`), []string{"beta"}, nil)
+		require.NoError(t, delimited.SetWordBoundaries(""))
+		assert.True(t, delimited.MatchElement(`x"beta"x`, "beta"))
+	})
+}
+
+func TestElementMatcher_caseInsensitive(t *testing.T) {
+	pairs := []options.DelimiterPair{{Left: `"`, Right: `"`}, {Left: "${", Right: "}"}}
+	matcher := NewElementMatcher("project", "", pairs, []string{"new-checkout"}, map[string][]string{"new-checkout": {"newCheckout"}})
+	matcher.SetCaseInsensitive(true)
+
+	assert.Equal(t, []string{"new-checkout"}, matcher.FindMatches("", `get("NEW-CHECKOUT")`))
+	assert.True(t, matcher.MatchElement("${New-Checkout}", "new-checkout"))
+	assert.Equal(t, []string{"NEW-CHECKOUT", "new-checkout"}, matcher.FindElementText(`"NEW-CHECKOUT" == "new-checkout"`, "new-checkout"))
+	assert.Equal(t, []string{"NEWCHECKOUT"}, matcher.FindAliases("", "NEWCHECKOUT", "new-checkout"))
+
+	caseSensitive := NewElementMatcher("project", "", pairs, []string{"new-checkout"}, nil)
+	assert.Empty(t, caseSensitive.FindMatches("", `get("NEW-CHECKOUT")`))
+	assert.Empty(t, caseSensitive.FindElementText(`get("NEW-CHECKOUT")`, "new-checkout"))
+}
+
+func TestElementMatcher_referencePatterns(t *testing.T) {
+	matcher := NewElementMatcher("project", "", delimiterPairs(defaultDelims), []string{"new-checkout", "dark-mode"}, nil)
+	require.NoError(t, matcher.SetReferencePatterns([]string{`LDFlag\(FLAG_KEY\)`, `^\s*(?P<flagKey>[\w-]+):`}))
+
+	specs := []struct {
+		name     string
+		line     string
+		expected []string
+	}{
+		{name: "FLAG_KEY placeholder", line: "LDFlag(new-checkout)", expected: []string{"new-checkout"}},
+		{name: "named group", line: "  dark-mode: true", expected: []string{"dark-mode"}},
+		{name: "unknown flag key", line: "LDFlag(unknown)", expected: []string{}},
+		{name: "delimiters and patterns", line: "LDFlag(new-checkout) 'dark-mode'", expected: []string{"dark-mode", "new-checkout"}},
+	}
+	for _, tt := range specs {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ElementsMatch(t, tt.expected, matcher.FindMatches("", tt.line))
+			for _, element := range tt.expected {
+				assert.True(t, matcher.MatchElement(tt.line, element))
+				assert.Equal(t, []string{element}, matcher.FindElementText(tt.line, element))
+			}
+		})
+	}
+
+	t.Run("invalid patterns", func(t *testing.T) {
+		assert.Error(t, matcher.SetReferencePatterns([]string{`variation\(`}))
+		assert.Error(t, matcher.SetReferencePatterns([]string{`(?P<key>\w+)`}))
 	})
 }
 
+func TestElementMatcher_keyTemplates(t *testing.T) {
+	flags := []string{"checkout-a", "checkout-b", "exp-banner", "dark-mode-beta", "dark-mode"}
+	templates := []string{"checkout-*", "exp-*", "*-beta", "unused-*"}
+	delimited := NewElementMatcher("project", "", delimiterPairs(defaultDelims), flags, nil)
+	delimited.SetKeyTemplates(templates)
+	bare := NewElementMatcher("project", "", nil, flags, nil)
+	bare.SetKeyTemplates(templates)
+
+	specs := []struct {
+		name     string
+		matcher  ElementMatcher
+		line     string
+		expected []string
+	}{
+		{name: "prefix", matcher: delimited, line: `variation("checkout-" + variant)`, expected: []string{"checkout-a", "checkout-b"}},
+		{name: "template literal", matcher: delimited, line: "variation(`exp-${name}`)", expected: []string{"exp-banner"}},
+		{name: "suffix", matcher: delimited, line: `name + "-beta"`, expected: []string{"dark-mode-beta"}},
+		{name: "exact match is not dynamic", matcher: delimited, line: `"checkout-a"`, expected: []string{"checkout-a"}},
+		{name: "other literal is not dynamic", matcher: delimited, line: `"checkout-c"`, expected: []string{}},
+		{name: "prefix without delimiter", matcher: delimited, line: `checkout-`, expected: []string{}},
+		{name: "without delimiters", matcher: bare, line: `checkout-{variant}`, expected: []string{"checkout-a", "checkout-b"}},
+		{name: "literal without delimiters", matcher: bare, line: `checkout-c`, expected: []string{}},
+	}
+	for _, tt := range specs {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ElementsMatch(t, tt.expected, tt.matcher.FindMatches("", tt.line))
+		})
+	}
+
+	assert.True(t, delimited.MatchKeyTemplate(`"checkout-" + variant`, "checkout-a"))
+	assert.False(t, delimited.MatchKeyTemplate(`"checkout-" + variant`, "exp-banner"))
+	assert.Len(t, delimited.keyTemplates, 3)
+}
+
+func TestElementMatcher_SearchesPath(t *testing.T) {
+	dir := NewElementMatcher("web", "web", nil, nil, nil)
+	paths := NewElementMatcher("services", "", nil, nil, nil)
+	paths.SetPaths([]string{"services/api", "apps/*", "**/*.tf"}, nil, []string{"apps/legacy/**"})
+
+	specs := []struct {
+		name    string
+		matcher ElementMatcher
+		path    string
+		want    bool
+	}{
+		{name: "in dir", matcher: dir, path: "web/app.js", want: true},
+		{name: "directory with dir as prefix", matcher: dir, path: "webhooks/app.js", want: false},
+		{name: "in directory path", matcher: paths, path: "services/api/main.go", want: true},
+		{name: "sibling of directory path", matcher: paths, path: "services/api-gateway/main.go", want: false},
+		{name: "in directory glob", matcher: paths, path: "apps/checkout/src/index.ts", want: true},
+		{name: "file glob", matcher: paths, path: "infra/main.tf", want: true},
+		{name: "ignored", matcher: paths, path: "apps/legacy/index.ts", want: false},
+		{name: "not in paths", matcher: paths, path: "README.md", want: false},
+	}
+	for _, tt := range specs {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.matcher.SearchesPath(tt.path))
+		})
+	}
+}
+
+func TestMatcher_ForRepository(t *testing.T) {
+	matcher := Matcher{Elements: []ElementMatcher{NewElementMatcher("default", "", nil, nil, nil)}}
+	api := matcher.ForRepository([]string{"services/api"}, nil)
+	services := matcher.ForRepository([]string{"services/*"}, []string{"services/api"})
+	root := matcher.ForRepository(nil, []string{"services/api", "services/*"})
+
+	assert.True(t, api.Elements[0].SearchesPath("services/api/main.go"))
+	assert.False(t, api.Elements[0].SearchesPath("services/web/app.js"))
+	assert.True(t, services.Elements[0].SearchesPath("services/web/app.js"))
+	assert.False(t, services.Elements[0].SearchesPath("services/api/main.go"))
+	assert.True(t, root.Elements[0].SearchesPath("README.md"))
+	assert.False(t, root.Elements[0].SearchesPath("services/web/app.js"))
+	// the original matcher is unchanged
+	assert.True(t, matcher.Elements[0].SearchesPath("services/web/app.js"))
+}
+
 func TestMatcher_MatchElement(t *testing.T) {
 	specs := []struct {
 		name     string
@@ -40,21 +281,21 @@ func TestMatcher_MatchElement(t *testing.T) {
 			name:     "match found",
 			expected: true,
 			line:     "var flagKey = 'testflag'",
-			matcher:  Matcher{Elements: []ElementMatcher{NewElementMatcher("projKey", "", ",'\"", []string{"testflag"}, map[string][]string{"testflag": {"testFlag"}})}},
+			matcher:  Matcher{Elements: []ElementMatcher{NewElementMatcher("projKey", "", delimiterPairs(",'\""), []string{"testflag"}, map[string][]string{"testflag": {"testFlag"}})}},
 			flagKey:  "testflag",
 		},
 		{
 			name:     "no match found",
 			expected: false,
 			line:     "var flagKey = 'testflag'",
-			matcher:  Matcher{Elements: []ElementMatcher{NewElementMatcher("projKey", "", ",'\"", []string{"anotherflag"}, map[string][]string{"anotherflag": {"anotherFlag"}})}},
+			matcher:  Matcher{Elements: []ElementMatcher{NewElementMatcher("projKey", "", delimiterPairs(",'\""), []string{"anotherflag"}, map[string][]string{"anotherflag": {"anotherFlag"}})}},
 			flagKey:  "testflag",
 		},
 		{
 			name:     "doesn't match when delimiters aren't present",
 			expected: false,
 			line:     "var TEST_FLAG",
-			matcher:  Matcher{Elements: []ElementMatcher{NewElementMatcher("projKey", "", "'", []string{"TEST_FLAG"}, map[string][]string{"testflag": {}})}},
+			matcher:  Matcher{Elements: []ElementMatcher{NewElementMatcher("projKey", "", delimiterPairs("'"), []string{"TEST_FLAG"}, map[string][]string{"testflag": {}})}},
 			flagKey:  "TEST_FLAG",
 		},
 		{
@@ -62,9 +303,23 @@ func TestMatcher_MatchElement(t *testing.T) {
 			name:     "matches without delimiters",
 			expected: true,
 			line:     "var TEST_FLAG",
-			matcher:  Matcher{Elements: []ElementMatcher{NewElementMatcher("projKey", "", "", []string{"TEST_FLAG"}, map[string][]string{"testflag": {}})}},
+			matcher:  Matcher{Elements: []ElementMatcher{NewElementMatcher("projKey", "", nil, []string{"TEST_FLAG"}, map[string][]string{"testflag": {}})}},
 			flagKey:  "TEST_FLAG",
 		},
+		{
+			name:     "matches annotation",
+			expected: true,
+			line:     "// ld-flag: testflag",
+			matcher:  Matcher{Elements: []ElementMatcher{NewElementMatcher("projKey", "", delimiterPairs("'"), []string{"testflag"}, nil)}},
+			flagKey:  "testflag",
+		},
+		{
+			name:     "doesn't match ignored line",
+			expected: false,
+			line:     "var flagKey = 'testflag' // ld-coderefs-ignore",
+			matcher:  Matcher{Elements: []ElementMatcher{NewElementMatcher("projKey", "", delimiterPairs("'"), []string{"testflag"}, nil)}},
+			flagKey:  "testflag",
+		},
 	}
 
 	for _, tt := range specs {
@@ -0,0 +1,46 @@
+package search
+
+import (
+	"fmt"
+
+	regexp "github.com/wasilibs/go-re2"
+
+	"github.com/launchdarkly/ld-find-code-refs/v2/options"
+)
+
+// referencePattern is a regular expression matching references, and the index of the capture group containing the flag key
+type referencePattern struct {
+	re         *regexp.Regexp
+	groupIndex int
+}
+
+// SetReferencePatterns adds regular expressions matching references to elements. Each pattern must contain the FLAG_KEY placeholder,
+// or a capture group named flagKey. A match counts as a reference if the captured text is one of the elements.
+func (m *ElementMatcher) SetReferencePatterns(patterns []string) error {
+	m.referencePatterns = make([]referencePattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(options.ExpandReferencePattern(pattern))
+		if err != nil {
+			return fmt.Errorf("could not compile reference pattern '%s': %w", pattern, err)
+		}
+		groupIndex := re.SubexpIndex(options.FlagKeyGroup)
+		if groupIndex < 0 {
+			return fmt.Errorf("reference pattern '%s' must contain FLAG_KEY or a capture group named '%s'", pattern, options.FlagKeyGroup)
+		}
+		m.referencePatterns = append(m.referencePatterns, referencePattern{re: re, groupIndex: groupIndex})
+	}
+	return nil
+}
+
+// findReferences returns the elements captured by reference patterns in a line
+func (m ElementMatcher) findReferences(line string) []string {
+	elements := make([]string, 0)
+	for _, p := range m.referencePatterns {
+		for _, submatch := range p.re.FindAllStringSubmatch(line, -1) {
+			if _, ok := m.elementSet[submatch[p.groupIndex]]; ok {
+				elements = append(elements, submatch[p.groupIndex])
+			}
+		}
+	}
+	return elements
+}
@@ -2,6 +2,7 @@ package search
 
 import (
 	"path/filepath"
+	"strings"
 
 	"github.com/launchdarkly/ld-find-code-refs/v2/flags"
 	"github.com/launchdarkly/ld-find-code-refs/v2/internal/ld"
@@ -19,10 +20,64 @@ func Scan(opts options.Options, repoParams ld.RepoParams, dir string) (Matcher,
 		searchDir = filepath.Join(dir, opts.Subdirectory)
 	}
 
-	refs, err := SearchForRefs(searchDir, opts.Subdirectory, matcher)
+	scope := Scope{Subdirectory: opts.Subdirectory, Matcher: matcher, FindUnknownFlags: findsUnknownFlags(opts)}
+	result, err := SearchForRefs(searchDir, scope)
 	if err != nil {
 		log.Error.Fatalf("error searching for flag key references: %s", err)
 	}
 
-	return matcher, refs
+	if scope.FindUnknownFlags {
+		reportUnknownFlags(options.UnknownFlagPolicy(opts.UnknownFlags).Canonical(), result.UnknownFlags)
+	}
+
+	return matcher, result.References
+}
+
+// ScanDiscovered searches the subdirectory of each configuration with its own options in a single walk of dir
+func ScanDiscovered(configs []options.Options, repoParams []ld.RepoParams, dir string) ([]Matcher, [][]ld.ReferenceHunksRep) {
+	scopes := make([]Scope, 0, len(configs))
+	matchers := make([]Matcher, 0, len(configs))
+	for i, opts := range configs {
+		flagKeys := flags.GetFlagKeys(opts, repoParams[i])
+		matcher := NewMultiProjectMatcher(opts, dir, flagKeys)
+		matchers = append(matchers, matcher)
+		scopes = append(scopes, Scope{Subdirectory: opts.Subdirectory, Matcher: matcher, FindUnknownFlags: findsUnknownFlags(opts)})
+	}
+	// restrict each matcher to its own files, so removed flags are also only found in them
+	for i := range scopes {
+		scopes[i].Matcher = scopes[i].Matcher.ForScope(scopes[i].Subdirectory, nestedSubdirectories(scopes, i))
+		matchers[i] = scopes[i].Matcher
+	}
+
+	results, err := SearchScopes(dir, scopes)
+	if err != nil {
+		log.Error.Fatalf("error searching for flag key references: %s", err)
+	}
+
+	refs := make([][]ld.ReferenceHunksRep, 0, len(results))
+	for i, result := range results {
+		if scopes[i].FindUnknownFlags {
+			reportUnknownFlags(options.UnknownFlagPolicy(configs[i].UnknownFlags).Canonical(), result.UnknownFlags)
+		}
+		refs = append(refs, result.References)
+	}
+	return matchers, refs
+}
+
+func findsUnknownFlags(opts options.Options) bool {
+	policy := options.UnknownFlagPolicy(opts.UnknownFlags).Canonical()
+	return policy == options.WarnUnknownFlags || policy == options.ErrorUnknownFlags
+}
+
+func reportUnknownFlags(policy options.UnknownFlagPolicy, unknownFlags []UnknownFlag) {
+	for _, u := range unknownFlags {
+		if len(u.Suggestions) > 0 {
+			log.Warning.Printf("unknown flag key %q at %s:%d, did you mean %s?", u.Key, u.Path, u.LineNumber, strings.Join(u.Suggestions, ", "))
+		} else {
+			log.Warning.Printf("unknown flag key %q at %s:%d", u.Key, u.Path, u.LineNumber)
+		}
+	}
+	if policy == options.ErrorUnknownFlags && len(unknownFlags) > 0 {
+		log.Error.Fatalf("found %d unknown flag keys in SDK evaluation calls", len(unknownFlags))
+	}
 }
@@ -0,0 +1,172 @@
+package search
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/ignore"
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/ld"
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/validation"
+)
+
+// Scope is a subdirectory of the repository searched with its own configuration
+type Scope struct {
+	// Path relative to the repository root, or empty for the whole repository
+	Subdirectory string
+	Matcher      Matcher
+	// Whether to find flag keys in SDK evaluation calls that are not flags
+	FindUnknownFlags bool
+}
+
+type ScopeResult struct {
+	References   []ld.ReferenceHunksRep
+	UnknownFlags []UnknownFlag
+}
+
+// DiscoverConfigDirectories returns the subdirectories of directory containing a .launchdarkly/coderefs.yaml file, relative to directory.
+// Ignored and hidden directories are not searched.
+func DiscoverConfigDirectories(directory string) ([]string, error) {
+	allIgnores := ignore.New(directory, ignore.Files)
+	workspace := filepath.ToSlash(directory)
+	ret := []string{}
+	err := filepath.WalkDir(directory, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		path = filepath.ToSlash(path)
+		if path == workspace {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") || allIgnores.Match(path, true) {
+			return filepath.SkipDir
+		}
+		if validation.FileExists(filepath.Join(path, ".launchdarkly", "coderefs.yaml")) {
+			ret = append(ret, strings.TrimPrefix(path, workspace+"/"))
+		}
+		return nil
+	})
+	return ret, err
+}
+
+// SearchScopes searches every scope in a single walk of the directory. Each file is only searched by the scope with the deepest
+// subdirectory containing it, so subdirectories with their own scope are excluded from their parents.
+func SearchScopes(directory string, scopes []Scope) ([]ScopeResult, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	files := make(chan file)
+	errs := make(chan error, 1)
+	ignored := scopeIgnores(directory, scopes)
+	go func() {
+		errs <- walkFiles(ctx, files, directory, "", ignored)
+	}()
+
+	results := make([]ScopeResult, len(scopes))
+	for i := range results {
+		results[i].References = []ld.ReferenceHunksRep{}
+	}
+	mu := sync.Mutex{}
+	w := sync.WaitGroup{}
+	for f := range files {
+		i := scopeForPath(scopes, f.path)
+		if i < 0 {
+			continue
+		}
+		w.Add(1)
+		go func(i int, f file) {
+			defer w.Done()
+			reference := f.toHunks(scopes[i].Matcher)
+			var unknownFlags []UnknownFlag
+			if scopes[i].FindUnknownFlags {
+				unknownFlags = f.unknownFlags(scopes[i].Matcher)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if reference != nil {
+				results[i].References = append(results[i].References, *reference)
+			}
+			results[i].UnknownFlags = append(results[i].UnknownFlags, unknownFlags...)
+		}(i, f)
+	}
+	w.Wait()
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		sort.SliceStable(results[i].References, func(a, b int) bool {
+			return results[i].References[a].Path < results[i].References[b].Path
+		})
+		results[i].References = limitReferences(results[i].References)
+		sortUnknownFlags(results[i].UnknownFlags)
+	}
+	return results, nil
+}
+
+// scopeIgnores returns a function matching paths ignored by the ignore files in the directory, or by the ignore files in the
+// subdirectory of a scope, which only apply to paths in that subdirectory
+func scopeIgnores(directory string, scopes []Scope) func(path string, isDir bool) bool {
+	rootIgnores := ignore.New(directory, ignore.Files)
+	workspace := filepath.ToSlash(directory)
+	scopeIgnores := make(map[string]ignore.Ignore, len(scopes))
+	for _, s := range scopes {
+		if s.Subdirectory != "" {
+			scopeIgnores[s.Subdirectory] = ignore.New(filepath.Join(directory, s.Subdirectory), ignore.Files)
+		}
+	}
+	return func(path string, isDir bool) bool {
+		if rootIgnores.Match(path, isDir) {
+			return true
+		}
+		relPath := strings.TrimPrefix(path, workspace+"/")
+		for subdirectory, i := range scopeIgnores {
+			if inDir(subdirectory, relPath) && i.Match(path, isDir) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// nestedSubdirectories returns the subdirectories of the scopes inside the subdirectory of scopes[i]
+func nestedSubdirectories(scopes []Scope, i int) []string {
+	ret := []string{}
+	for j, s := range scopes {
+		if j != i && s.Subdirectory != scopes[i].Subdirectory && (scopes[i].Subdirectory == "" || inDir(scopes[i].Subdirectory, s.Subdirectory)) {
+			ret = append(ret, s.Subdirectory)
+		}
+	}
+	return ret
+}
+
+// scopeForPath returns the index of the scope with the deepest subdirectory containing path, or -1
+func scopeForPath(scopes []Scope, path string) int {
+	ret := -1
+	for i, s := range scopes {
+		if s.Subdirectory != "" && !inDir(s.Subdirectory, path) {
+			continue
+		}
+		if ret < 0 || len(s.Subdirectory) > len(scopes[ret].Subdirectory) {
+			ret = i
+		}
+	}
+	return ret
+}
+
+// limitReferences applies the limits on the number of files and hunks of SearchForRefs
+func limitReferences(refs []ld.ReferenceHunksRep) []ld.ReferenceHunksRep {
+	totalHunks := 0
+	for i, reference := range refs {
+		if i+1 >= maxFileCount {
+			return refs[:i+1]
+		}
+		totalHunks += len(reference.Hunks)
+		if totalHunks > maxHunkCount {
+			return refs[:i+1]
+		}
+	}
+	return refs
+}
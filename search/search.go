@@ -10,6 +10,7 @@ import (
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/launchdarkly/ld-find-code-refs/v2/internal/helpers"
 	"github.com/launchdarkly/ld-find-code-refs/v2/internal/ld"
+	"github.com/launchdarkly/ld-find-code-refs/v2/options"
 )
 
 const (
@@ -38,18 +39,50 @@ func truncateLine(line string, maxCharCount int) string {
 type file struct {
 	path  string
 	lines []string
+	// Assigns kinds to references, if set
+	classifier *classifier
 }
 
-// hunkForLine returns a matching code reference for a given flag key on a line
-func (f file) hunkForLine(projKey, flagKey string, lineNum int, matcher Matcher) *ld.HunkRep {
+// hunkForLine returns a matching code reference for a given flag key on a line. references are the elements captured by reference
+// patterns in the line, so the patterns are not evaluated again for each flag key.
+func (f file) hunkForLine(projKey, flagKey string, lineNum int, matcher Matcher, references []string) *ld.HunkRep {
 	line := f.lines[lineNum]
-	ctxLines := matcher.ctxLines
+	ctxLines := matcher.contextLines(projKey)
 
-	aliasMatches := matcher.FindAliases(line, flagKey)
-	if len(aliasMatches) == 0 && !matcher.MatchElement(line, flagKey) {
+	aliasMatches := matcher.FindAliases(f.path, line, flagKey)
+	elementMatches := matcher.findElementText(line, flagKey, references)
+	annotated := isAnnotated(line, flagKey)
+	var confidence string
+	if len(aliasMatches) == 0 && len(elementMatches) == 0 && !annotated {
+		if !matcher.MatchKeyTemplate(line, flagKey) {
+			return nil
+		}
+		confidence = ld.LowConfidence
+	}
+
+	// ld-flag annotations are usually written in comments, but declare references, so they are never ignored or tagged as comments
+	if !annotated && matcher.comments == options.IgnoreComments && f.classifier != nil && f.classifier.inComment(lineNum) {
 		return nil
 	}
 
+	// classify the line before context lines are truncated
+	var kind string
+	switch {
+	case f.classifier == nil:
+	case annotated:
+		kind = f.classifier.codeKind(line)
+	default:
+		kind = f.classifier.kind(lineNum, line)
+	}
+
+	// text that only matched the flag key when ignoring case
+	var matchedText []string
+	for _, text := range elementMatches {
+		if text != flagKey {
+			matchedText = append(matchedText, text)
+		}
+	}
+
 	startingLineNum := lineNum
 	var hunkLines []string
 	if ctxLines >= 0 {
@@ -78,16 +111,20 @@ func (f file) hunkForLine(projKey, flagKey string, lineNum int, matcher Matcher)
 		StartingLineNumber: startingLineNum + 1,
 		Lines:              lines,
 		Aliases:            aliasMatches,
+		MatchedText:        matchedText,
 		ContentHash:        contentHash,
+		Kind:               kind,
+		Annotated:          annotated,
+		Confidence:         confidence,
 	}
 	return &ret
 }
 
 // aggregateHunksForFlag finds all references in a file, and combines matches if their context lines overlap
-func (f file) aggregateHunksForFlag(projKey, flagKey string, matcher Matcher, lineNumbers []int) []ld.HunkRep {
+func (f file) aggregateHunksForFlag(projKey, flagKey string, matcher Matcher, lineNumbers []int, referencesByLine map[int][]string) []ld.HunkRep {
 	var hunksForFlag []ld.HunkRep
 	for _, lineNumber := range lineNumbers {
-		match := f.hunkForLine(projKey, flagKey, lineNumber, matcher)
+		match := f.hunkForLine(projKey, flagKey, lineNumber, matcher, referencesByLine[lineNumber])
 		if match != nil {
 			lastHunkIdx := len(hunksForFlag) - 1
 			// If the previous hunk overlaps or is adjacent to the current hunk, merge them together
@@ -106,18 +143,18 @@ func (f file) toHunks(matcher Matcher) *ld.ReferenceHunksRep {
 	filteredMatchers := make([]ElementMatcher, 0)
 
 	for _, elementSearch := range matcher.Elements {
-		if elementSearch.Dir != "" {
-			matchDir := strings.HasPrefix(f.path, elementSearch.Dir)
-			if !matchDir {
-				continue
-			}
+		if elementSearch.SearchesPath(f.path) {
+			filteredMatchers = append(filteredMatchers, elementSearch)
 		}
-		filteredMatchers = append(filteredMatchers, elementSearch)
 	}
+	if len(filteredMatchers) == 0 || IsIgnoredFile(f.lines) {
+		return nil
+	}
+	f.classifier = newClassifier(f)
 	for _, elementSearch := range filteredMatchers {
-		lineNumbersByElement := f.findMatchingLineNumbersByElement(elementSearch)
+		lineNumbersByElement, referencesByLine := f.findMatchingLineNumbersByElement(elementSearch)
 		for element, lineNumbers := range lineNumbersByElement {
-			hunks = append(hunks, f.aggregateHunksForFlag(elementSearch.ProjKey, element, matcher, lineNumbers)...)
+			hunks = append(hunks, f.aggregateHunksForFlag(elementSearch.ProjKey, element, matcher, lineNumbers, referencesByLine)...)
 		}
 	}
 	if len(hunks) == 0 {
@@ -126,14 +163,24 @@ func (f file) toHunks(matcher Matcher) *ld.ReferenceHunksRep {
 	return &ld.ReferenceHunksRep{Path: f.path, Hunks: hunks}
 }
 
-func (f file) findMatchingLineNumbersByElement(matcher ElementMatcher) map[string][]int {
+// findMatchingLineNumbersByElement returns the numbers of the lines referencing each element, and the elements captured by reference
+// patterns in each line
+func (f file) findMatchingLineNumbersByElement(matcher ElementMatcher) (map[string][]int, map[int][]string) {
 	lineNumbersByElement := make(map[string][]int)
+	referencesByLine := make(map[int][]string)
 	for lineNum, line := range f.lines {
-		for _, element := range matcher.FindMatches(line) {
+		if IsIgnoredLine(line) {
+			continue
+		}
+		references := matcher.findReferences(line)
+		if len(references) > 0 {
+			referencesByLine[lineNum] = references
+		}
+		for _, element := range matcher.findMatches(f.path, line, references) {
 			lineNumbersByElement[element] = append(lineNumbersByElement[element], lineNum)
 		}
 	}
-	return lineNumbersByElement
+	return lineNumbersByElement, referencesByLine
 }
 
 // mergeHunks combines the lines and aliases of two hunks together for a given file
@@ -167,14 +214,32 @@ func mergeHunks(a, b ld.HunkRep) []ld.HunkRep {
 			ProjKey:            a.ProjKey,
 			FlagKey:            a.FlagKey,
 			Aliases:            helpers.Dedupe(append(a.Aliases, b.Aliases...)),
+			MatchedText:        helpers.Dedupe(append(a.MatchedText, b.MatchedText...)),
 			ContentHash:        contentHash,
+			Kind:               mergeKinds(a.Kind, b.Kind),
+			Annotated:          a.Annotated || b.Annotated,
+			Confidence:         mergeConfidence(a.Confidence, b.Confidence),
 		},
 	}
 }
 
-// processFiles starts goroutines to process files individually. When all files have completed processing, the references channel is closed to signal completion.
-func processFiles(ctx context.Context, files <-chan file, references chan<- ld.ReferenceHunksRep, matcher Matcher) {
-	defer close(references)
+// mergeConfidence returns the confidence of a hunk combining references of both confidences. A hunk with any exact reference is exact.
+func mergeConfidence(a, b string) string {
+	if a == "" || b == "" {
+		return ""
+	}
+	return a
+}
+
+// fileResult holds the references and unknown flag keys found in a file
+type fileResult struct {
+	reference    *ld.ReferenceHunksRep
+	unknownFlags []UnknownFlag
+}
+
+// processFiles starts goroutines to process files individually. When all files have completed processing, the results channel is closed to signal completion.
+func processFiles(ctx context.Context, files <-chan file, results chan<- fileResult, matcher Matcher, findUnknownFlags bool) {
+	defer close(results)
 	w := sync.WaitGroup{}
 	for f := range files {
 		if ctx.Err() != nil {
@@ -183,9 +248,12 @@ func processFiles(ctx context.Context, files <-chan file, references chan<- ld.R
 		}
 		w.Add(1)
 		go func(f file) {
-			reference := f.toHunks(matcher)
-			if reference != nil {
-				references <- *reference
+			result := fileResult{reference: f.toHunks(matcher)}
+			if findUnknownFlags {
+				result.unknownFlags = f.unknownFlags(matcher)
+			}
+			if result.reference != nil || len(result.unknownFlags) > 0 {
+				results <- result
 			}
 			w.Done()
 		}(f)
@@ -193,39 +261,45 @@ func processFiles(ctx context.Context, files <-chan file, references chan<- ld.R
 	w.Wait()
 }
 
-func SearchForRefs(directory, subdirectory string, matcher Matcher) ([]ld.ReferenceHunksRep, error) {
+// SearchForRefs searches the directory for references to the flags of the scope, and for unknown flag keys if the scope finds them,
+// in a single walk. The subdirectory of the scope is prepended to the paths of files.
+func SearchForRefs(directory string, scope Scope) (ScopeResult, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	files := make(chan file)
-	references := make(chan ld.ReferenceHunksRep)
+	results := make(chan fileResult)
 	// Start workers to process files asynchronously as they are written to the files channel
-	go processFiles(ctx, files, references, matcher)
+	go processFiles(ctx, files, results, scope.Matcher, scope.FindUnknownFlags)
 
-	err := readFiles(ctx, files, directory, subdirectory)
+	err := readFiles(ctx, files, directory, scope.Subdirectory)
 	if err != nil {
-		return nil, err
+		return ScopeResult{}, err
 	}
 
-	ret := make([]ld.ReferenceHunksRep, 0, len(references))
-
-	defer sort.SliceStable(ret, func(i, j int) bool {
-		return ret[i].Path < ret[j].Path
-	})
-
+	ret := ScopeResult{References: []ld.ReferenceHunksRep{}}
 	totalHunks := 0
-	for reference := range references {
-		ret = append(ret, reference)
+	for result := range results {
+		ret.UnknownFlags = append(ret.UnknownFlags, result.unknownFlags...)
+		if result.reference == nil {
+			continue
+		}
+		ret.References = append(ret.References, *result.reference)
 
 		// Reached maximum number of files with code references
-		if len(ret) >= maxFileCount {
-			return ret, nil
+		if len(ret.References) >= maxFileCount {
+			break
 		}
-		totalHunks += len(reference.Hunks)
+		totalHunks += len(result.reference.Hunks)
 		// Reached maximum number of hunks across all files
 		if totalHunks > maxHunkCount {
-			return ret, nil
+			break
 		}
 	}
+
+	sort.SliceStable(ret.References, func(i, j int) bool {
+		return ret.References[i].Path < ret.References[j].Path
+	})
+	sortUnknownFlags(ret.UnknownFlags)
 	return ret, nil
 }
 
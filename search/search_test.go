@@ -3,11 +3,14 @@ package search
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/launchdarkly/ld-find-code-refs/v2/internal/ld"
 	"github.com/launchdarkly/ld-find-code-refs/v2/internal/log"
+	"github.com/launchdarkly/ld-find-code-refs/v2/options"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -70,7 +73,7 @@ func Test_hunkForLine(t *testing.T) {
 			matcher: Matcher{
 				ctxLines: 0,
 				Elements: []ElementMatcher{
-					NewElementMatcher("my-project", ``, `"`, []string{testFlagKey}, nil),
+					NewElementMatcher("my-project", ``, delimiterPairs(`"`), []string{testFlagKey}, nil),
 				},
 			},
 			lineNum: 0,
@@ -83,7 +86,7 @@ func Test_hunkForLine(t *testing.T) {
 			matcher: Matcher{
 				ctxLines: 0,
 				Elements: []ElementMatcher{
-					NewElementMatcher("my-project", ``, `"`, []string{testFlagKey}, nil),
+					NewElementMatcher("my-project", ``, delimiterPairs(`"`), []string{testFlagKey}, nil),
 				},
 			},
 			lineNum: 0,
@@ -96,7 +99,7 @@ func Test_hunkForLine(t *testing.T) {
 			matcher: Matcher{
 				ctxLines: -1,
 				Elements: []ElementMatcher{
-					NewElementMatcher("my-project", ``, ``, []string{testFlagKey}, nil),
+					NewElementMatcher("my-project", ``, nil, []string{testFlagKey}, nil),
 				},
 			},
 			lineNum: 0,
@@ -109,7 +112,7 @@ func Test_hunkForLine(t *testing.T) {
 			matcher: Matcher{
 				ctxLines: -1,
 				Elements: []ElementMatcher{
-					NewElementMatcher("my-project", ``, ``, nil, testAliases),
+					NewElementMatcher("my-project", ``, nil, nil, testAliases),
 				},
 			},
 			lineNum: 0,
@@ -122,7 +125,7 @@ func Test_hunkForLine(t *testing.T) {
 			matcher: Matcher{
 				ctxLines: -1,
 				Elements: []ElementMatcher{
-					NewElementMatcher("my-project", ``, ``, nil, testAliases),
+					NewElementMatcher("my-project", ``, nil, nil, testAliases),
 				},
 			},
 			lineNum: 0,
@@ -135,7 +138,7 @@ func Test_hunkForLine(t *testing.T) {
 			matcher: Matcher{
 				ctxLines: 0,
 				Elements: []ElementMatcher{
-					NewElementMatcher("my-project", ``, ``, []string{testFlagKey}, nil),
+					NewElementMatcher("my-project", ``, nil, []string{testFlagKey}, nil),
 				},
 			},
 			lineNum: 1,
@@ -148,7 +151,7 @@ func Test_hunkForLine(t *testing.T) {
 			matcher: Matcher{
 				ctxLines: 1,
 				Elements: []ElementMatcher{
-					NewElementMatcher("my-project", ``, ``, []string{testFlagKey}, nil),
+					NewElementMatcher("my-project", ``, nil, []string{testFlagKey}, nil),
 				},
 			},
 			lineNum: 1,
@@ -161,7 +164,7 @@ func Test_hunkForLine(t *testing.T) {
 			matcher: Matcher{
 				ctxLines: 0,
 				Elements: []ElementMatcher{
-					NewElementMatcher("my-project", ``, ``, []string{testFlagKey}, nil),
+					NewElementMatcher("my-project", ``, nil, []string{testFlagKey}, nil),
 				},
 			},
 			lineNum: 0,
@@ -174,7 +177,7 @@ func Test_hunkForLine(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			f := file{lines: tt.lines}
-			got := f.hunkForLine("default", tt.flagKey, tt.lineNum, tt.matcher)
+			got := f.hunkForLine("default", tt.flagKey, tt.lineNum, tt.matcher, nil)
 			require.Equal(t, tt.want, got)
 		})
 	}
@@ -193,7 +196,7 @@ func Test_aggregateHunksForFlag(t *testing.T) {
 			name: "does not set lines when context lines are disabled",
 			matcher: Matcher{
 				ctxLines: -1,
-				Elements: []ElementMatcher{NewElementMatcher("default", ``, defaultDelims, []string{testFlagKey}, nil)},
+				Elements: []ElementMatcher{NewElementMatcher("default", ``, delimiterPairs(defaultDelims), []string{testFlagKey}, nil)},
 			},
 			lines: []string{delimitedTestFlagKey, delimitedTestFlagKey, delimitedTestFlagKey},
 			want: []ld.HunkRep{
@@ -206,7 +209,7 @@ func Test_aggregateHunksForFlag(t *testing.T) {
 			name: "combines adjacent hunks with no additional context lines",
 			matcher: Matcher{
 				ctxLines: 0,
-				Elements: []ElementMatcher{NewElementMatcher("default", ``, defaultDelims, []string{testFlagKey}, nil)},
+				Elements: []ElementMatcher{NewElementMatcher("default", ``, delimiterPairs(defaultDelims), []string{testFlagKey}, nil)},
 			}, lines: []string{delimitedTestFlagKey, delimitedTestFlagKey, delimitedTestFlagKey},
 			want: []ld.HunkRep{
 				makeHunk(1, delimitedTestFlagKey, delimitedTestFlagKey, delimitedTestFlagKey),
@@ -216,7 +219,7 @@ func Test_aggregateHunksForFlag(t *testing.T) {
 			name: "combines adjacent hunks",
 			matcher: Matcher{
 				ctxLines: 1,
-				Elements: []ElementMatcher{NewElementMatcher("default", ``, defaultDelims, []string{testFlagKey}, nil)},
+				Elements: []ElementMatcher{NewElementMatcher("default", ``, delimiterPairs(defaultDelims), []string{testFlagKey}, nil)},
 			}, lines: []string{delimitedTestFlagKey, "", "", delimitedTestFlagKey, "", "", delimitedTestFlagKey},
 			want: []ld.HunkRep{
 				makeHunk(1, delimitedTestFlagKey, "", "", delimitedTestFlagKey, "", "", delimitedTestFlagKey),
@@ -226,7 +229,7 @@ func Test_aggregateHunksForFlag(t *testing.T) {
 			name: "does not combine hunks with no overlap",
 			matcher: Matcher{
 				ctxLines: 1,
-				Elements: []ElementMatcher{NewElementMatcher("default", ``, defaultDelims, []string{testFlagKey}, nil)},
+				Elements: []ElementMatcher{NewElementMatcher("default", ``, delimiterPairs(defaultDelims), []string{testFlagKey}, nil)},
 			},
 			lines: []string{delimitedTestFlagKey, "", "", "", delimitedTestFlagKey, "", "", "", delimitedTestFlagKey},
 			want: []ld.HunkRep{
@@ -239,7 +242,7 @@ func Test_aggregateHunksForFlag(t *testing.T) {
 			name: "combines overlapping hunks",
 			matcher: Matcher{
 				ctxLines: 1,
-				Elements: []ElementMatcher{NewElementMatcher("default", ``, defaultDelims, []string{testFlagKey}, nil)},
+				Elements: []ElementMatcher{NewElementMatcher("default", ``, delimiterPairs(defaultDelims), []string{testFlagKey}, nil)},
 			},
 			lines: []string{delimitedTestFlagKey, "", delimitedTestFlagKey, "", delimitedTestFlagKey},
 			want: []ld.HunkRep{
@@ -250,13 +253,32 @@ func Test_aggregateHunksForFlag(t *testing.T) {
 			name: "combines multiple types of overlaps",
 			matcher: Matcher{
 				ctxLines: 1,
-				Elements: []ElementMatcher{NewElementMatcher("default", ``, defaultDelims, []string{testFlagKey}, nil)},
+				Elements: []ElementMatcher{NewElementMatcher("default", ``, delimiterPairs(defaultDelims), []string{testFlagKey}, nil)},
 			},
 			lines: []string{delimitedTestFlagKey, "", delimitedTestFlagKey, "", delimitedTestFlagKey},
 			want: []ld.HunkRep{
 				makeHunk(1, delimitedTestFlagKey, "", delimitedTestFlagKey, "", delimitedTestFlagKey),
 			},
 		},
+		{
+			name: "records text of case-insensitive matches",
+			matcher: Matcher{
+				ctxLines: 0,
+				Elements: []ElementMatcher{func() ElementMatcher {
+					m := NewElementMatcher("default", ``, delimiterPairs(defaultDelims), []string{testFlagKey}, nil)
+					m.SetCaseInsensitive(true)
+					return m
+				}()},
+			},
+			lines: []string{`"SOMEFLAG"`, delimitedTestFlagKey},
+			want: []ld.HunkRep{
+				func() ld.HunkRep {
+					hunk := makeHunk(1, `"SOMEFLAG"`, delimitedTestFlagKey)
+					hunk.MatchedText = []string{"SOMEFLAG"}
+					return hunk
+				}(),
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -266,7 +288,7 @@ func Test_aggregateHunksForFlag(t *testing.T) {
 			for i := range tt.lines {
 				lineNumbers = append(lineNumbers, i)
 			}
-			got := f.aggregateHunksForFlag("default", testFlagKey, tt.matcher, lineNumbers)
+			got := f.aggregateHunksForFlag("default", testFlagKey, tt.matcher, lineNumbers, nil)
 			require.Equal(t, tt.want, got)
 		})
 	}
@@ -344,7 +366,7 @@ func Test_toHunks(t *testing.T) {
 	matcher := Matcher{
 		ctxLines: 0,
 		Elements: []ElementMatcher{
-			NewElementMatcher("default", "", "", []string{testFlagKey, testFlagKey2}, testAliases),
+			NewElementMatcher("default", "", nil, []string{testFlagKey, testFlagKey2}, testAliases),
 		},
 	}
 	got := f.toHunks(matcher)
@@ -354,12 +376,149 @@ func Test_toHunks(t *testing.T) {
 	emptyMatcher := Matcher{
 		ctxLines: 0,
 		Elements: []ElementMatcher{
-			NewElementMatcher("default", "", "", nil, nil),
+			NewElementMatcher("default", "", nil, nil, nil),
 		},
 	}
 	require.Nil(t, f.toHunks(emptyMatcher))
 }
 
+func Test_toHunks_comments(t *testing.T) {
+	f := file{
+		path:  "flags.py",
+		lines: []string{`# "new-checkout" is no longer used`, `x = 1`, `client.variation("dark-mode", context, False)`},
+	}
+	specs := []struct {
+		policy options.CommentPolicy
+		want   []ld.HunkRep
+	}{
+		{policy: options.IncludeComments, want: []ld.HunkRep{
+			{ProjKey: "default", FlagKey: "new-checkout", StartingLineNumber: 1, Lines: f.lines[0], Aliases: []string{}, Kind: ld.CommentKind},
+			{ProjKey: "default", FlagKey: "dark-mode", StartingLineNumber: 3, Lines: f.lines[2], Aliases: []string{}, Kind: ld.EvaluationKind},
+		}},
+		{policy: options.IgnoreComments, want: []ld.HunkRep{
+			{ProjKey: "default", FlagKey: "dark-mode", StartingLineNumber: 3, Lines: f.lines[2], Aliases: []string{}, Kind: ld.EvaluationKind},
+		}},
+	}
+	for _, tt := range specs {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			matcher := Matcher{
+				comments: tt.policy,
+				Elements: []ElementMatcher{
+					NewElementMatcher("default", "", delimiterPairs(`"`), []string{"new-checkout", "dark-mode"}, nil),
+				},
+			}
+			got := f.toHunks(matcher)
+			require.NotNil(t, got)
+			for i := range got.Hunks {
+				got.Hunks[i].ContentHash = ""
+			}
+			assert.ElementsMatch(t, tt.want, got.Hunks)
+		})
+	}
+}
+
+func Test_toHunks_annotations(t *testing.T) {
+	matcher := Matcher{
+		Elements: []ElementMatcher{
+			NewElementMatcher("default", "", delimiterPairs(`"`), []string{"new-checkout", "dark-mode"}, nil),
+		},
+	}
+	f := file{
+		path: "flags.js",
+		lines: []string{
+			`const old = "new-checkout" // ld-coderefs-ignore`,
+			`// ld-flag: new-checkout, dark-mode, unknown-flag`,
+			"const key = `new-${name}`",
+		},
+	}
+	got := f.toHunks(matcher)
+	require.NotNil(t, got)
+	for i := range got.Hunks {
+		got.Hunks[i].ContentHash = ""
+	}
+	assert.ElementsMatch(t, []ld.HunkRep{
+		{ProjKey: "default", FlagKey: "new-checkout", StartingLineNumber: 2, Lines: f.lines[1], Aliases: []string{}, Annotated: true},
+		{ProjKey: "default", FlagKey: "dark-mode", StartingLineNumber: 2, Lines: f.lines[1], Aliases: []string{}, Annotated: true},
+	}, got.Hunks)
+
+	ignored := file{path: "flags.js", lines: []string{`// ld-coderefs-ignore-file`, `const key = "new-checkout"`}}
+	assert.Nil(t, ignored.toHunks(matcher))
+}
+
+func Test_toHunks_annotatedComments(t *testing.T) {
+	f := file{
+		path:  "flags.py",
+		lines: []string{`# ld-flag: new-checkout`, `x = 1`, `# "dark-mode" is no longer used`},
+	}
+	annotated := ld.HunkRep{ProjKey: "default", FlagKey: "new-checkout", StartingLineNumber: 1, Lines: f.lines[0], Aliases: []string{}, Annotated: true}
+	specs := []struct {
+		policy options.CommentPolicy
+		want   []ld.HunkRep
+	}{
+		{policy: options.IgnoreComments, want: []ld.HunkRep{annotated}},
+		{policy: options.TagComments, want: []ld.HunkRep{
+			annotated,
+			{ProjKey: "default", FlagKey: "dark-mode", StartingLineNumber: 3, Lines: f.lines[2], Aliases: []string{}, Kind: ld.CommentKind},
+		}},
+	}
+	for _, tt := range specs {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			matcher := Matcher{
+				comments: tt.policy,
+				Elements: []ElementMatcher{
+					NewElementMatcher("default", "", delimiterPairs(`"`), []string{"new-checkout", "dark-mode"}, nil),
+				},
+			}
+			got := f.toHunks(matcher)
+			require.NotNil(t, got)
+			for i := range got.Hunks {
+				got.Hunks[i].ContentHash = ""
+			}
+			assert.ElementsMatch(t, tt.want, got.Hunks)
+		})
+	}
+}
+
+func Test_toHunks_referencePatterns(t *testing.T) {
+	elementMatcher := NewElementMatcher("default", "", delimiterPairs(`"`), []string{"new-checkout", "dark-mode"}, nil)
+	require.NoError(t, elementMatcher.SetReferencePatterns([]string{`LDFlag\(FLAG_KEY\)`}))
+	matcher := Matcher{ctxLines: -1, Elements: []ElementMatcher{elementMatcher}}
+	f := file{path: "flags.go", lines: []string{"LDFlag(new-checkout)", `variation("dark-mode")`, "LDFlag(unknown)"}}
+
+	lineNumbersByElement, referencesByLine := f.findMatchingLineNumbersByElement(elementMatcher)
+	assert.Equal(t, map[string][]int{"new-checkout": {0}, "dark-mode": {1}}, lineNumbersByElement)
+	assert.Equal(t, map[int][]string{0: {"new-checkout"}}, referencesByLine)
+
+	got := f.toHunks(matcher)
+	require.NotNil(t, got)
+	lines := map[string]int{}
+	for _, hunk := range got.Hunks {
+		lines[hunk.FlagKey] = hunk.StartingLineNumber
+	}
+	assert.Equal(t, map[string]int{"new-checkout": 1, "dark-mode": 2}, lines)
+}
+
+func Test_hunkForLine_keyTemplates(t *testing.T) {
+	elementMatcher := NewElementMatcher("default", "", delimiterPairs(`"`), []string{"checkout-a"}, nil)
+	elementMatcher.SetKeyTemplates([]string{"checkout-*"})
+	matcher := Matcher{ctxLines: -1, Elements: []ElementMatcher{elementMatcher}}
+	f := file{path: "checkout.js", lines: []string{`variation("checkout-" + variant)`, `variation("checkout-a")`}}
+
+	got := f.hunkForLine("default", "checkout-a", 0, matcher, nil)
+	require.NotNil(t, got)
+	assert.Equal(t, ld.LowConfidence, got.Confidence)
+	got = f.hunkForLine("default", "checkout-a", 1, matcher, nil)
+	require.NotNil(t, got)
+	assert.Empty(t, got.Confidence)
+	assert.Equal(t, "", mergeConfidence(ld.LowConfidence, ""))
+}
+
+func Test_annotatedKeys(t *testing.T) {
+	assert.Equal(t, []string{"new-checkout", "dark-mode"}, annotatedKeys(`# ld-flag: new-checkout,dark-mode`))
+	assert.Equal(t, []string{"a", "b"}, annotatedKeys(`/* ld-flag: a */ /* ld-flag:b */`))
+	assert.Empty(t, annotatedKeys(`old-flag: true`))
+}
+
 func Test_processFiles(t *testing.T) {
 	f := testFile
 	linesCopy := make([]string, len(f.lines))
@@ -367,7 +526,7 @@ func Test_processFiles(t *testing.T) {
 	f2 := file{path: f.path + "2", lines: linesCopy}
 
 	files := make(chan file, 3)
-	references := make(chan ld.ReferenceHunksRep, 3)
+	results := make(chan fileResult, 3)
 	files <- f
 	files <- f2
 	files <- file{path: "no-refs"}
@@ -376,14 +535,14 @@ func Test_processFiles(t *testing.T) {
 		ctxLines: 0,
 	}
 	matcher.Elements = append(matcher.Elements,
-		NewElementMatcher("default", "", "", []string{testFlagKey, testFlagKey2}, testAliases),
+		NewElementMatcher("default", "", nil, []string{testFlagKey, testFlagKey2}, testAliases),
 	)
-	go processFiles(context.Background(), files, references, matcher)
+	go processFiles(context.Background(), files, results, matcher, false)
 	totalRefs := 0
 	totalHunks := 0
-	for reference := range references {
+	for result := range results {
 		totalRefs++
-		totalHunks += len(reference.Hunks)
+		totalHunks += len(result.reference.Hunks)
 	}
 	require.Equal(t, 2, totalRefs, "The file with no references should not have been added to refs")
 	require.Equal(t, 8, totalHunks, "See Test_toHunks for a more comprehensive example of why this should be 4 per file (2 files with the same refs)")
@@ -394,16 +553,16 @@ func Test_SearchForRefs(t *testing.T) {
 
 	matcher := Matcher{ctxLines: 0}
 	matcher.Elements = append(matcher.Elements,
-		NewElementMatcher("default", "", "", []string{testFlagKey, testFlagKey2}, nil),
+		NewElementMatcher("default", "", nil, []string{testFlagKey, testFlagKey2}, nil),
 	)
 
 	t.Run("without subdirectory option finds both files", func(t *testing.T) {
-		actual, err := SearchForRefs("testdata/exclude-github-files", "", matcher)
+		actual, err := SearchForRefs("testdata/exclude-github-files", Scope{Matcher: matcher})
 		require.NoError(t, err)
-		require.Len(t, actual, 2)
+		require.Len(t, actual.References, 2)
 
 		var foundFirst, foundSecond bool
-		for _, r := range actual {
+		for _, r := range actual.References {
 			switch r.Path {
 			case testFile.path:
 				foundFirst = true
@@ -418,10 +577,10 @@ func Test_SearchForRefs(t *testing.T) {
 	})
 
 	t.Run("with subdirectory option finds only the file in the subdirectory", func(t *testing.T) {
-		actual, err := SearchForRefs("testdata/exclude-github-files/subdir", "subdir", matcher)
+		actual, err := SearchForRefs("testdata/exclude-github-files/subdir", Scope{Subdirectory: "subdir", Matcher: matcher})
 		require.NoError(t, err)
-		require.Len(t, actual, 1)
-		require.Equal(t, testFileWithSubdir.path, actual[0].Path)
+		require.Len(t, actual.References, 1)
+		require.Equal(t, testFileWithSubdir.path, actual.References[0].Path)
 	})
 
 	t.Cleanup(func() { os.Remove("testdata/exclude-github-files/symlink") })
@@ -495,3 +654,197 @@ func makeHunk(startingLineNumber int, lines ...string) ld.HunkRep {
 func delimit(s string, delim string) string {
 	return delim + s + delim
 }
+
+func Test_classifier(t *testing.T) {
+	goLines := []string{
+		`package flags`,
+		`const NewCheckout = "new-checkout"`,
+		`// "new-checkout" is enabled by default`,
+		`func enabled() bool {`,
+		`	return client.BoolVariation("new-checkout", ctx, false)`,
+		`}`,
+		`var keys = []string{"new-checkout"}`,
+		`/* disabled:`,
+		`"new-checkout" */`,
+	}
+	specs := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{name: "go", path: "pkg/flags.go", want: []string{"", ld.DeclarationKind, ld.CommentKind, "", ld.EvaluationKind, "", "", ld.CommentKind, ld.CommentKind}},
+		{name: "test file", path: "pkg/flags_test.go", want: []string{ld.TestKind, ld.TestKind, ld.CommentKind}},
+		{name: "test directory", path: "src/__tests__/flags.js", want: []string{ld.TestKind}},
+		{name: "config file", path: "config/flags.yaml", want: []string{ld.ConfigKind}},
+		{name: "unsupported language", path: "README.md", want: []string{""}},
+	}
+	for _, tt := range specs {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newClassifier(file{path: tt.path, lines: goLines})
+			for i, want := range tt.want {
+				assert.Equal(t, want, c.kind(i, goLines[i]), "line %d", i+1)
+			}
+		})
+	}
+}
+
+func Test_mergeKinds(t *testing.T) {
+	assert.Equal(t, ld.EvaluationKind, mergeKinds(ld.CommentKind, ld.EvaluationKind))
+	assert.Equal(t, ld.EvaluationKind, mergeKinds(ld.EvaluationKind, ld.DeclarationKind))
+	assert.Equal(t, "", mergeKinds(ld.CommentKind, ""))
+	assert.Equal(t, ld.CommentKind, mergeKinds(ld.CommentKind, ld.CommentKind))
+}
+
+func Test_unknownFlags(t *testing.T) {
+	matcher := Matcher{
+		Elements: []ElementMatcher{
+			NewElementMatcher("default", "", delimiterPairs(`"`), []string{"new-checkout", "new-checkouts", "dark-mode"}, nil),
+			NewElementMatcher("web", "web/", delimiterPairs(`"`), []string{"banner"}, nil),
+		},
+	}
+	f := file{
+		path: "web/app.js",
+		lines: []string{
+			`client.variation("new-chekout", ctx, false)`,
+			`client.variation("banner", ctx, false)`,
+			`client.variation("zzz-unrelated", ctx, false)`,
+			`client.variation("typo-flag", ctx, false) // ld-coderefs-ignore`,
+			`log("new-chekout")`,
+		},
+	}
+	assert.Equal(t, []UnknownFlag{
+		{Path: "web/app.js", LineNumber: 1, Key: "new-chekout", Suggestions: []string{"new-checkout"}},
+		{Path: "web/app.js", LineNumber: 3, Key: "zzz-unrelated", Suggestions: []string{}},
+	}, f.unknownFlags(matcher))
+
+	assert.Nil(t, file{path: "README.md", lines: f.lines}.unknownFlags(matcher))
+}
+
+func Test_SearchForRefs_unknownFlags(t *testing.T) {
+	dir := t.TempDir()
+	content := "package flags\n\nvar _ = client.BoolVariation(\"dark-mode\", ctx, false)\nvar _ = client.BoolVariation(\"dark-mdoe\", ctx, false)\nvar _ = client.BoolVariation(\"ui\", ctx, false)\n"
+	require.NoError(t, os.WriteFile(dir+"/flags.go", []byte(content), 0o600))
+	elementMatcher := NewElementMatcher("default", "", delimiterPairs(`"`), []string{"dark-mode"}, nil)
+	// "ui" is a flag, but is not searched for because its key is too short
+	elementMatcher.SetFlagKeys([]string{"dark-mode", "ui"})
+	matcher := Matcher{Elements: []ElementMatcher{elementMatcher}}
+
+	got, err := SearchForRefs(dir, Scope{Matcher: matcher, FindUnknownFlags: true})
+	require.NoError(t, err)
+	require.Len(t, got.References, 1)
+	assert.Equal(t, []UnknownFlag{{Path: "flags.go", LineNumber: 4, Key: "dark-mdoe", Suggestions: []string{"dark-mode"}}}, got.UnknownFlags)
+
+	got, err = SearchForRefs(dir, Scope{Matcher: matcher})
+	require.NoError(t, err)
+	require.Len(t, got.References, 1)
+	assert.Empty(t, got.UnknownFlags)
+}
+
+func Test_toHunks_projectSettings(t *testing.T) {
+	oneLine := 0
+	web := NewElementMatcher("web", "", delimiterPairs(`"`), []string{testFlagKey}, nil)
+	web.SetPaths(nil, []string{"web/**"}, []string{"web/vendor/**"})
+	web.SetContextLines(&oneLine)
+	infra := NewElementMatcher("infra", "", delimiterPairs(`"`), []string{testFlagKey}, nil)
+	infra.SetPaths(nil, []string{"**/*.tf"}, nil)
+	matcher := Matcher{ctxLines: 1, Elements: []ElementMatcher{web, infra}}
+
+	lines := []string{"a", delimit(testFlagKey, `"`), "b"}
+	got := file{path: "web/app.js", lines: lines}.toHunks(matcher)
+	require.NotNil(t, got)
+	require.Len(t, got.Hunks, 1)
+	assert.Equal(t, "web", got.Hunks[0].ProjKey)
+	assert.Equal(t, lines[1], got.Hunks[0].Lines)
+
+	got = file{path: "infra/main.tf", lines: lines}.toHunks(matcher)
+	require.NotNil(t, got)
+	require.Len(t, got.Hunks, 1)
+	assert.Equal(t, "infra", got.Hunks[0].ProjKey)
+	assert.Equal(t, strings.Join(lines, "\n"), got.Hunks[0].Lines)
+
+	assert.Nil(t, file{path: "web/vendor/lib.js", lines: lines}.toHunks(matcher))
+}
+
+func Test_SearchScopes(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(path, contents string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(contents), 0o600))
+	}
+	writeFile("app.js", `variation("root-flag")`)
+	writeFile("services/api/.launchdarkly/coderefs.yaml", "repoName: api\n")
+	writeFile("services/api/main.go", `BoolVariation("api-flag") // "root-flag"`)
+	writeFile("services/api-gateway/main.go", `BoolVariation("root-flag")`)
+	writeFile("node_modules/lib/.launchdarkly/coderefs.yaml", "repoName: lib\n")
+	writeFile(".gitignore", "node_modules\n")
+	// ignore files of a scope only apply to its subdirectory
+	writeFile("services/api/.ldignore", "generated\n")
+	writeFile("services/api/generated/flags.go", `BoolVariation("api-flag")`)
+	writeFile("generated/flags.js", `variation("root-flag")`)
+
+	subdirectories, err := DiscoverConfigDirectories(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"services/api"}, subdirectories)
+
+	scopes := []Scope{
+		{Matcher: Matcher{Elements: []ElementMatcher{NewElementMatcher("root", "", delimiterPairs(`"`), []string{"root-flag"}, nil)}}},
+		{Subdirectory: "services/api", Matcher: Matcher{Elements: []ElementMatcher{NewElementMatcher("api", "", delimiterPairs(`"`), []string{"api-flag"}, nil)}}},
+	}
+	results, err := SearchScopes(dir, scopes)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	paths := func(refs []ld.ReferenceHunksRep) []string {
+		ret := []string{}
+		for _, ref := range refs {
+			ret = append(ret, ref.Path)
+		}
+		return ret
+	}
+	assert.Equal(t, []string{"app.js", "generated/flags.js", "services/api-gateway/main.go"}, paths(results[0].References))
+	assert.Equal(t, []string{"services/api/main.go"}, paths(results[1].References))
+	assert.Equal(t, "api-flag", results[1].References[0].Hunks[0].FlagKey)
+}
+
+func TestMatcher_ForScope(t *testing.T) {
+	scopes := []Scope{
+		{Matcher: Matcher{Elements: []ElementMatcher{NewElementMatcher("root", "", nil, []string{"root-flag"}, nil)}}},
+		{Subdirectory: "services/api", Matcher: Matcher{Elements: []ElementMatcher{NewElementMatcher("api", "", nil, []string{"api-flag"}, nil)}}},
+		{Subdirectory: "services/api/internal", Matcher: Matcher{Elements: []ElementMatcher{NewElementMatcher("internal", "", nil, []string{"internal-flag"}, nil)}}},
+	}
+	assert.Equal(t, []string{"services/api", "services/api/internal"}, nestedSubdirectories(scopes, 0))
+	assert.Equal(t, []string{"services/api/internal"}, nestedSubdirectories(scopes, 1))
+	assert.Empty(t, nestedSubdirectories(scopes, 2))
+
+	root := scopes[0].Matcher.ForScope("", nestedSubdirectories(scopes, 0)).Elements[0]
+	assert.True(t, root.SearchesPath("app.js"))
+	assert.True(t, root.SearchesPath("services/api-gateway/main.go"))
+	assert.False(t, root.SearchesPath("services/api/main.go"))
+
+	api := scopes[1].Matcher.ForScope("services/api", nestedSubdirectories(scopes, 1)).Elements[0]
+	assert.True(t, api.SearchesPath("services/api/main.go"))
+	assert.False(t, api.SearchesPath("app.js"))
+	assert.False(t, api.SearchesPath("services/api/internal/flags.go"))
+}
+
+func TestSurveyRepository(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(path, contents string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(contents), 0600))
+	}
+	writeFile("services/api/go.mod", "module api\n")
+	writeFile("services/api/main.go", "if client.BoolVariation(\"new-checkout\", ctx, false) {\nenableNewCheckout := true\n}\n")
+	writeFile("services/api/internal/go.mod", "module internal\n")
+	writeFile("web/package.json", "{}\n")
+	writeFile("web/app.js", "const NEW_CHECKOUT = flags[<new-checkout>];\nrender(<new-checkout>, {NEW_CHECKOUT})\nnew-checkout-v2\n")
+
+	survey, err := SurveyRepository(dir, []string{"new-checkout"}, []string{"newCheckout", "NEW_CHECKOUT"})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]int{"go": 1, "javascript": 1}, survey.Files)
+	assert.Equal(t, map[string]int{"go": 1}, survey.EvaluationFiles)
+	assert.Equal(t, map[string]int{"NEW_CHECKOUT": 2}, survey.Candidates)
+	assert.Equal(t, map[options.DelimiterPair]int{{Left: `"`, Right: `"`}: 1, {Left: "<", Right: ">"}: 2}, survey.Delimiters)
+	assert.Equal(t, []string{"services/api", "web"}, survey.ProjectDirs)
+}
@@ -0,0 +1,132 @@
+package search
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	ahocorasick "github.com/petar-dambovaliev/aho-corasick"
+
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/lang"
+	"github.com/launchdarkly/ld-find-code-refs/v2/options"
+)
+
+// Files that mark the root of a package or service, used to find the directories of a monorepo
+var projectManifests = []string{
+	"go.mod", "package.json", "pom.xml", "build.gradle", "build.gradle.kts", "Cargo.toml", "pyproject.toml", "setup.py", "Gemfile", "Package.swift", "composer.json",
+}
+
+// Survey summarizes the contents of a repository to suggest a configuration for it
+type Survey struct {
+	// Number of files in each supported language
+	Files map[string]int
+	// Number of files in each supported language with LaunchDarkly or OpenFeature SDK evaluation calls
+	EvaluationFiles map[string]int
+	// Number of times each candidate string appears at a word boundary
+	Candidates map[string]int
+	// Number of times flag keys appear between each pair of punctuation characters, including the default delimiters
+	Delimiters map[options.DelimiterPair]int
+	// Directories below the root containing a package manifest such as go.mod or package.json, excluding nested directories
+	ProjectDirs []string
+}
+
+// SurveyRepository reads every file in a directory that would be searched for references, counting the languages and SDK evaluation calls,
+// the references to flag keys and the characters around them, and the occurrences of candidate aliases
+func SurveyRepository(directory string, flagKeys, candidates []string) (Survey, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	files := make(chan file)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- readFiles(ctx, files, directory, "")
+	}()
+
+	patterns := make([]string, 0, len(flagKeys)+len(candidates))
+	patterns = append(patterns, flagKeys...)
+	patterns = append(patterns, candidates...)
+	var matcher ahocorasick.AhoCorasick
+	if len(patterns) > 0 {
+		matcherBuilder := ahocorasick.NewAhoCorasickBuilder(ahocorasick.Opts{DFA: true, MatchKind: ahocorasick.StandardMatch})
+		matcher = matcherBuilder.Build(patterns)
+	}
+	identifiers, _ := newIdentifierChars("")
+
+	survey := Survey{
+		Files:           map[string]int{},
+		EvaluationFiles: map[string]int{},
+		Candidates:      map[string]int{},
+		Delimiters:      map[options.DelimiterPair]int{},
+	}
+	projectDirs := map[string]bool{}
+	for f := range files {
+		if dir := filepath.ToSlash(filepath.Dir(f.path)); dir != "." && isProjectManifest(filepath.Base(f.path)) {
+			projectDirs[dir] = true
+		}
+		l := lang.ForPath(f.path)
+		if l != nil {
+			survey.Files[l.Name]++
+		}
+		hasEvaluation := false
+		for _, line := range f.lines {
+			hasEvaluation = hasEvaluation || (l != nil && l.HasEvaluation(line))
+			if len(patterns) == 0 {
+				continue
+			}
+			iter := matcher.IterOverlapping(line)
+			for match := iter.Next(); match != nil; match = iter.Next() {
+				start, end := match.Start(), match.End()
+				if match.Pattern() >= len(flagKeys) {
+					if identifiers.atWordBoundary(line, start, end) {
+						survey.Candidates[patterns[match.Pattern()]]++
+					}
+					continue
+				}
+				if start > 0 && end < len(line) && flagKeyChars.atWordBoundary(line, start, end) && isPunct(line[start-1]) && isPunct(line[end]) {
+					survey.Delimiters[options.DelimiterPair{Left: line[start-1 : start], Right: line[end : end+1]}]++
+				}
+			}
+		}
+		if hasEvaluation {
+			survey.EvaluationFiles[l.Name]++
+		}
+	}
+	if err := <-errs; err != nil {
+		return Survey{}, err
+	}
+
+	survey.ProjectDirs = topLevelDirs(projectDirs)
+	return survey, nil
+}
+
+func isProjectManifest(name string) bool {
+	for _, m := range projectManifests {
+		if m == name {
+			return true
+		}
+	}
+	return strings.HasSuffix(name, ".csproj")
+}
+
+func isPunct(c byte) bool {
+	return c > ' ' && c < 0x7F && !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9')
+}
+
+// topLevelDirs returns the sorted directories that are not inside another directory of the set
+func topLevelDirs(dirs map[string]bool) []string {
+	ret := []string{}
+	for dir := range dirs {
+		nested := false
+		for parent := filepath.ToSlash(filepath.Dir(dir)); parent != "."; parent = filepath.ToSlash(filepath.Dir(parent)) {
+			if dirs[parent] {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			ret = append(ret, dir)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
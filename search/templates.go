@@ -0,0 +1,135 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/launchdarkly/ld-find-code-refs/v2/options"
+)
+
+// matches the characters of a flag key, or of the part of a flag key replaced by a wildcard
+var flagKeyChars, _ = newIdentifierChars(options.FlagKeyChars)
+
+// keyTemplate describes flag keys built at runtime, such as `checkout-*`
+type keyTemplate struct {
+	// Static text before the first wildcard and after the last wildcard
+	prefix string
+	suffix string
+	// Matches complete flag keys written literally, which are not dynamic references
+	literal *regexp.Regexp
+	// Elements matching the template
+	elements []string
+}
+
+func newKeyTemplate(template string, elements []string) keyTemplate {
+	parts := strings.Split(template, options.KeyTemplateWildcard)
+	quoted := make([]string, 0, len(parts))
+	for _, p := range parts {
+		quoted = append(quoted, regexp.QuoteMeta(p))
+	}
+	matchesElement := regexp.MustCompile("^" + strings.Join(quoted, ".*") + "$")
+	t := keyTemplate{
+		prefix:  parts[0],
+		suffix:  parts[len(parts)-1],
+		literal: regexp.MustCompile("^" + strings.Join(quoted, "["+options.FlagKeyChars+"]+") + "$"),
+	}
+	for _, element := range elements {
+		if matchesElement.MatchString(element) {
+			t.elements = append(t.elements, element)
+		}
+	}
+	return t
+}
+
+// SetKeyTemplates adds templates of flag keys built at runtime. When a line contains the static prefix or suffix of a template
+// inside delimiters, the line is a low-confidence reference to every element matching the template.
+func (m *ElementMatcher) SetKeyTemplates(templates []string) {
+	m.keyTemplates = make([]keyTemplate, 0, len(templates))
+	for _, template := range templates {
+		t := newKeyTemplate(template, m.Elements)
+		if len(t.elements) > 0 {
+			m.keyTemplates = append(m.keyTemplates, t)
+		}
+	}
+}
+
+// findTemplateReferences returns the elements matching key templates whose static parts are found in a line
+func (m ElementMatcher) findTemplateReferences(line string) []string {
+	elements := make([]string, 0)
+	for _, t := range m.keyTemplates {
+		if m.matchesKeyTemplate(line, t) {
+			elements = append(elements, t.elements...)
+		}
+	}
+	return elements
+}
+
+// MatchKeyTemplate returns true if the line is a low-confidence reference to the element through a key template
+func (m ElementMatcher) MatchKeyTemplate(line, element string) bool {
+	for _, t := range m.keyTemplates {
+		for _, e := range t.elements {
+			if e == element && m.matchesKeyTemplate(line, t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (m ElementMatcher) matchesKeyTemplate(line string, t keyTemplate) bool {
+	if t.prefix != "" && !m.hasDynamicFragment(line, t, t.prefix, true) {
+		return false
+	}
+	if t.suffix != "" && !m.hasDynamicFragment(line, t, t.suffix, false) {
+		return false
+	}
+	return true
+}
+
+// hasDynamicFragment returns true if the line contains the prefix of a template after a left delimiter, or its suffix before a right
+// delimiter, in a key that is not a complete flag key matching the template. Without delimiters, the fragment may be anywhere in the line.
+func (m ElementMatcher) hasDynamicFragment(line string, t keyTemplate, fragment string, isPrefix bool) bool {
+	if len(m.delimiters) == 0 {
+		return hasDynamicKey(line, t, fragment, 0, len(fragment), true, true)
+	}
+	for _, pair := range m.delimiters {
+		var found bool
+		if isPrefix {
+			found = hasDynamicKey(line, t, pair.Left+fragment, len(pair.Left), len(pair.Left)+len(fragment), false, true)
+		} else {
+			found = hasDynamicKey(line, t, fragment+pair.Right, 0, len(fragment), true, false)
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDynamicKey returns true if text is found in the line, and the key containing text[keyStart:keyEnd] is not a literal key
+// matching the template. The key is extended with flag key characters on each side where it isn't bounded by a delimiter.
+func hasDynamicKey(line string, t keyTemplate, text string, keyStart, keyEnd int, extendLeft, extendRight bool) bool {
+	for offset := 0; ; {
+		i := strings.Index(line[offset:], text)
+		if i < 0 {
+			return false
+		}
+		i += offset
+		offset = i + 1
+		start, end := i+keyStart, i+keyEnd
+		for extendLeft && start > 0 && isFlagKeyChar(line[start-1]) {
+			start--
+		}
+		for extendRight && end < len(line) && isFlagKeyChar(line[end]) {
+			end++
+		}
+		if !t.literal.MatchString(line[start:end]) {
+			return true
+		}
+	}
+}
+
+func isFlagKeyChar(c byte) bool {
+	return c < utf8.RuneSelf && flagKeyChars.ascii[c]
+}
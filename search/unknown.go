@@ -0,0 +1,88 @@
+package search
+
+import (
+	"sort"
+
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/helpers"
+	"github.com/launchdarkly/ld-find-code-refs/v2/internal/lang"
+)
+
+const maxSuggestions = 3 // Maximum number of similar flag keys suggested for an unknown flag key
+
+// UnknownFlag is a flag key evaluated by an SDK call that is not a flag in any project searching the file
+type UnknownFlag struct {
+	Path       string
+	LineNumber int
+	Key        string
+	// Nearest flag keys by edit distance
+	Suggestions []string
+}
+
+func sortUnknownFlags(unknownFlags []UnknownFlag) {
+	sort.SliceStable(unknownFlags, func(i, j int) bool {
+		if unknownFlags[i].Path != unknownFlags[j].Path {
+			return unknownFlags[i].Path < unknownFlags[j].Path
+		}
+		return unknownFlags[i].LineNumber < unknownFlags[j].LineNumber
+	})
+}
+
+func (f file) unknownFlags(matcher Matcher) []UnknownFlag {
+	l := lang.ForPath(f.path)
+	if l == nil || IsIgnoredFile(f.lines) {
+		return nil
+	}
+
+	known := make(map[string]struct{})
+	for _, elementSearch := range matcher.Elements {
+		if !elementSearch.SearchesPath(f.path) {
+			continue
+		}
+		for _, key := range elementSearch.knownFlagKeys() {
+			known[key] = struct{}{}
+		}
+	}
+	// without any flags, every key would be unknown
+	if len(known) == 0 {
+		return nil
+	}
+
+	var ret []UnknownFlag
+	for lineNum, line := range f.lines {
+		if IsIgnoredLine(line) {
+			continue
+		}
+		for _, key := range l.EvaluatedKeys(line) {
+			if _, ok := known[key]; ok {
+				continue
+			}
+			ret = append(ret, UnknownFlag{Path: f.path, LineNumber: lineNum + 1, Key: key, Suggestions: suggestFlagKeys(key, known)})
+		}
+	}
+	return ret
+}
+
+// suggestFlagKeys returns the known flag keys nearest to an unknown key, if they are close enough to be a likely typo
+func suggestFlagKeys(key string, known map[string]struct{}) []string {
+	maxDistance := len(key) / 3 //nolint:mnd
+	if maxDistance < 1 {
+		maxDistance = 1
+	}
+	best := maxDistance + 1
+	suggestions := []string{}
+	for k := range known {
+		d := helpers.EditDistance(key, k)
+		switch {
+		case d < best:
+			best = d
+			suggestions = []string{k}
+		case d == best:
+			suggestions = append(suggestions, k)
+		}
+	}
+	sort.Strings(suggestions)
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+	return suggestions
+}